@@ -0,0 +1,266 @@
+package gomovie
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// Interpolation describes how a value changes from one keyframe to the next
+type Interpolation int
+
+const (
+	// Linear changes the value at a constant speed
+	Linear Interpolation = iota
+	// Step holds the value until the next keyframe
+	Step
+	// Bezier uses the Bezier easing curve of the keyframe
+	Bezier
+)
+
+// Easing curves for Bezier interpolation. Same as the css timing functions.
+var (
+	EaseIn    = [4]float64{.42, 0, 1, 1}
+	EaseOut   = [4]float64{0, 0, .58, 1}
+	EaseInOut = [4]float64{.42, 0, .58, 1}
+)
+
+// Keyframe describes a value at a certain time
+type Keyframe struct {
+	Time  float32
+	Value float64
+
+	// Interpolation towards the next keyframe
+	Interpolation Interpolation
+
+	// Control points x1, y1, x2, y2 of the easing curve (like css cubic-bezier). Only used for Bezier interpolation.
+	Bezier [4]float64
+}
+
+// Keyframes describes a value which changes over time. The keyframes should be sorted by time.
+// Before the first and after the last keyframe the value is held.
+type Keyframes []Keyframe
+
+// Constant creates Keyframes with a value which never changes
+func Constant(v float64) Keyframes {
+	return Keyframes{{Value: v}}
+}
+
+// At returns the value at time t
+func (k Keyframes) At(t float32) float64 {
+	if len(k) == 0 {
+		return 0
+	}
+
+	//first keyframe after t
+	n := sort.Search(len(k), func(i int) bool { return k[i].Time > t })
+
+	if n == 0 {
+		return k[0].Value
+	}
+	if n == len(k) {
+		return k[n-1].Value
+	}
+
+	from, to := k[n-1], k[n]
+
+	p := float64(t-from.Time) / float64(to.Time-from.Time)
+
+	switch from.Interpolation {
+	case Step:
+		return from.Value
+	case Bezier:
+		p = cubicBezierEase(from.Bezier, p)
+	}
+
+	return from.Value + (to.Value-from.Value)*p
+}
+
+// cubicBezierEase returns the progress y of the easing curve for progress x. The curve starts at 0,0 and ends at 1,1.
+func cubicBezierEase(c [4]float64, x float64) float64 {
+	bezier := func(t, p1, p2 float64) float64 {
+		it := 1 - t
+		return 3*it*it*t*p1 + 3*it*t*t*p2 + t*t*t
+	}
+
+	//the curve is monotonic in x so we can find t with bisection
+	lo, hi := 0., 1.
+	t := x
+	for i := 0; i < 30; i++ {
+		if bezier(t, c[0], c[2]) < x {
+			lo = t
+		} else {
+			hi = t
+		}
+		t = (lo + hi) / 2
+	}
+
+	return bezier(t, c[1], c[3])
+}
+
+// AnimatedPoint describes a position which changes over time
+type AnimatedPoint struct {
+	X, Y Keyframes
+}
+
+// At returns the position at time t
+func (p AnimatedPoint) At(t float32) (x, y float64) {
+	return p.X.At(t), p.Y.At(t)
+}
+
+// AnimatedColor describes a color which changes over time. Each component has a value between 0 and 255.
+type AnimatedColor struct {
+	R, G, B, A Keyframes
+}
+
+// ConstantColor creates an AnimatedColor which never changes
+func ConstantColor(c color.Color) AnimatedColor {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return AnimatedColor{
+		R: Constant(float64(n.R)),
+		G: Constant(float64(n.G)),
+		B: Constant(float64(n.B)),
+		A: Constant(float64(n.A)),
+	}
+}
+
+// At returns the color at time t
+func (c AnimatedColor) At(t float32) color.NRGBA {
+	return color.NRGBA{
+		R: clampUint8(float32(c.R.At(t))),
+		G: clampUint8(float32(c.G.At(t))),
+		B: clampUint8(float32(c.B.At(t))),
+		A: clampUint8(float32(c.A.At(t))),
+	}
+}
+
+// NewOpacityTransform creates a FrameTransform which multiplies the alpha of each frame with the opacity (0 - 1) at the time of the frame
+func NewOpacityTransform(opacity Keyframes) FrameTransform {
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			o := opacity.At(f.Time)
+			if o >= 1 {
+				return
+			}
+
+			for i := 3; i < len(f.Data); i += 4 {
+				f.Data[i] = clampUint8(float32(float64(f.Data[i]) * o))
+			}
+		},
+	}
+}
+
+// NewPanZoomTransform creates a FrameTransform for Ken Burns like effects. For each frame an area around center
+// (in pixels of the source frame) is scaled to width x height. A scale of 1 means one source pixel becomes one output pixel.
+func NewPanZoomTransform(width, height int, center AnimatedPoint, scale Keyframes, filter ResizeFilter) FrameTransform {
+	return NewAnimatedCropTransform(width, height, filter, func(t float32) image.Rectangle {
+		cx, cy := center.At(t)
+		s := scale.At(t)
+
+		w := float64(width) / s
+		h := float64(height) / s
+
+		x0 := int(math.Floor(cx - w/2 + .5))
+		y0 := int(math.Floor(cy - h/2 + .5))
+
+		return image.Rect(x0, y0, x0+int(w+.5), y0+int(h+.5))
+	})
+}
+
+// NewAnimatedRotateTransform creates a FrameTransform which rotates each frame clockwise around its center by the given degrees.
+// The frame keeps its size. Corners which are rotated outside are lost and uncovered areas become transparent.
+func NewAnimatedRotateTransform(degrees Keyframes) FrameTransform {
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			a := degrees.At(f.Time) * math.Pi / 180
+			if a == 0 {
+				return
+			}
+
+			sin, cos := math.Sincos(a)
+			cx, cy := float64(f.Width)/2, float64(f.Height)/2
+
+			dst := make([]byte, len(f.Data))
+
+			for y := 0; y < f.Height; y++ {
+				for x := 0; x < f.Width; x++ {
+					//inverse rotation to find the source position
+					dx, dy := float64(x)+.5-cx, float64(y)+.5-cy
+					sx := cos*dx + sin*dy + cx - .5
+					sy := -sin*dx + cos*dy + cy - .5
+
+					sampleBilinear(f.Data, f.Width, f.Height, sx, sy, dst[(y*f.Width+x)*4:])
+				}
+			}
+
+			f.Data = dst
+		},
+	}
+}
+
+// sampleBilinear writes the bilinear interpolated pixel at x, y of the w x h rgba data to out.
+// Pixels outside of the data are transparent.
+func sampleBilinear(src []byte, w, h int, x, y float64, out []byte) {
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	var r, g, b, a float64
+
+	for j := 0; j < 2; j++ {
+		for i := 0; i < 2; i++ {
+			px, py := x0+i, y0+j
+			if px < 0 || py < 0 || px >= w || py >= h {
+				continue
+			}
+
+			wx := fx
+			if i == 0 {
+				wx = 1 - fx
+			}
+			wy := fy
+			if j == 0 {
+				wy = 1 - fy
+			}
+
+			p := src[(py*w+px)*4:]
+			pa := float64(p[3]) * wx * wy
+			r += float64(p[0]) * pa
+			g += float64(p[1]) * pa
+			b += float64(p[2]) * pa
+			a += pa
+		}
+	}
+
+	if a <= 0 {
+		out[0], out[1], out[2], out[3] = 0, 0, 0, 0
+		return
+	}
+
+	out[0] = clampUint8(float32(r / a))
+	out[1] = clampUint8(float32(g / a))
+	out[2] = clampUint8(float32(b / a))
+	out[3] = clampUint8(float32(a))
+}
+
+// NewVolumeTransform creates a SampleTransform which multiplies each sample with the volume at the time of the sample
+func NewVolumeTransform(volume Keyframes) SampleTransform {
+	return SampleTransform{
+		Transform: func(s *SampleBlock) {
+			f := s.Floats()
+			if len(f) == 0 {
+				return
+			}
+
+			channels := intMax(s.Channels, 1)
+			frames := len(f) / channels
+
+			for i := range f {
+				t := s.Time + s.Duration*float32(i/channels)/float32(frames)
+				f[i] *= float32(volume.At(t))
+			}
+
+			s.SetFloats(f)
+		},
+	}
+}
@@ -0,0 +1,148 @@
+package gomovie_test
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestKeyframes(t *testing.T) {
+	k := gomovie.Keyframes{
+		{Time: 0, Value: 0},
+		{Time: 1, Value: 10, Interpolation: gomovie.Step},
+		{Time: 2, Value: 20, Interpolation: gomovie.Bezier, Bezier: gomovie.EaseInOut},
+		{Time: 3, Value: 40},
+	}
+
+	tests := []struct {
+		t float32
+		v float64
+	}{
+		{-1, 0},
+		{.5, 5},
+		{1.5, 10},
+		{2.5, 30}, //ease in out is symmetric so halfway is still halfway
+		{10, 40},
+	}
+
+	for _, test := range tests {
+		if v := k.At(test.t); math.Abs(v-test.v) > 1e-3 {
+			t.Fatalf("Expected %v at %v but got %v", test.v, test.t, v)
+		}
+	}
+
+	if v := k.At(2.25); v >= 25 {
+		t.Fatalf("Ease in should start slow but got %v", v)
+	}
+}
+
+func TestOpacityTransform(t *testing.T) {
+	f := solidFrame(2, 1, color.NRGBA{255, 255, 255, 255})
+	f.Time = 1
+
+	gomovie.NewOpacityTransform(gomovie.Keyframes{{Time: 0, Value: 1}, {Time: 2, Value: 0}}).Transform(f)
+
+	for i := 0; i < len(f.Data); i += 4 {
+		if f.Data[i] != 255 || f.Data[i+3] != 128 {
+			t.Fatalf("Expected a half transparent white pixel but got %v", f.Data[i:i+4])
+		}
+	}
+}
+
+func TestPanZoomTransform(t *testing.T) {
+	//left half red and right half blue
+	src := solidFrame(4, 4, color.NRGBA{255, 0, 0, 255})
+	for y := 0; y < 4; y++ {
+		copy(src.Data[(y*4+2)*4:], []byte{0, 0, 255, 255, 0, 0, 255, 255})
+	}
+
+	center := gomovie.AnimatedPoint{
+		X: gomovie.Keyframes{{Time: 0, Value: 1}, {Time: 1, Value: 3}},
+		Y: gomovie.Constant(2),
+	}
+	transform := gomovie.NewPanZoomTransform(2, 2, center, gomovie.Constant(1), gomovie.NearestNeighbor)
+
+	tests := []struct {
+		t float32
+		c []byte
+	}{
+		{0, []byte{255, 0, 0, 255}},
+		{1, []byte{0, 0, 255, 255}},
+	}
+
+	for _, test := range tests {
+		f := &gomovie.Frame{Data: append([]byte(nil), src.Data...), Width: 4, Height: 4, Time: test.t}
+		transform.Resize(f)
+
+		if f.Width != 2 || f.Height != 2 || len(f.Data) != 2*2*4 {
+			t.Fatalf("Expected a 2x2 frame but got %vx%v", f.Width, f.Height)
+		}
+
+		for i := 0; i < len(f.Data); i += 4 {
+			if string(f.Data[i:i+4]) != string(test.c) {
+				t.Fatalf("Expected %v at %v but got %v", test.c, test.t, f.Data[i:i+4])
+			}
+		}
+	}
+}
+
+func TestAnimatedRotateTransform(t *testing.T) {
+	//red pixel at the top left of a black frame
+	data := []byte{
+		255, 0, 0, 255, 0, 0, 0, 255,
+		0, 0, 0, 255, 0, 0, 0, 255,
+	}
+
+	transform := gomovie.NewAnimatedRotateTransform(gomovie.Keyframes{{Time: 0, Value: 0}, {Time: 1, Value: 90}})
+
+	f := &gomovie.Frame{Data: append([]byte(nil), data...), Width: 2, Height: 2, Time: 0}
+	transform.Transform(f)
+	if string(f.Data) != string(data) {
+		t.Fatalf("Expected an unchanged frame at 0 degrees but got %v", f.Data)
+	}
+
+	f = &gomovie.Frame{Data: append([]byte(nil), data...), Width: 2, Height: 2, Time: 1}
+	transform.Transform(f)
+
+	//rotated clockwise so the red pixel moves to the top right
+	if f.Data[4] < 250 || f.Data[5] > 5 || f.Data[0] > 5 {
+		t.Fatalf("Expected the red pixel at the top right but got %v", f.Data)
+	}
+}
+
+func TestAnimatedColor(t *testing.T) {
+	c := gomovie.AnimatedColor{
+		R: gomovie.Keyframes{{Time: 0, Value: 0}, {Time: 1, Value: 255}},
+		G: gomovie.Constant(300),
+		A: gomovie.Constant(255),
+	}
+
+	if v := c.At(.5); v.R != 128 || v.G != 255 || v.B != 0 || v.A != 255 {
+		t.Fatalf("Expected the red halfway, a clamped green and no blue but got %v", v)
+	}
+
+	if v := gomovie.ConstantColor(color.NRGBA{10, 20, 30, 40}).At(5); v != (color.NRGBA{10, 20, 30, 40}) {
+		t.Fatalf("Expected a constant color but got %v", v)
+	}
+}
+
+func TestVolumeTransform(t *testing.T) {
+	sb := &gomovie.SampleBlock{
+		SampleFormat: &gomovie.SampleFormat{Depth: 32, Float: true},
+		Data:         []gomovie.SampleFloat32{1, 1, 1, 1, 1, 1, 1, 1},
+		Channels:     2,
+		Duration:     1,
+	}
+
+	gomovie.NewVolumeTransform(gomovie.Keyframes{{Time: 0, Value: 0}, {Time: 1, Value: 1}}).Transform(sb)
+
+	//both channels of a frame get the same volume
+	expected := []float32{0, 0, .25, .25, .5, .5, .75, .75}
+	for i, v := range sb.Floats() {
+		if math.Abs(float64(v-expected[i])) > 1e-6 {
+			t.Fatalf("Expected %v at %v but got %v", expected[i], i, v)
+		}
+	}
+}
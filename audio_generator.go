@@ -0,0 +1,284 @@
+package gomovie
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+)
+
+var invalidSweepFrequencyError = errors.New("Sweep frequencies should be above 0")
+
+// generatorSampleReader creates the samples with a function of the sample index (in the source) and channel.
+// The function returns a value between -1 and 1. Each reader (and slice) creates its own function with newSample
+// so functions with state are never shared.
+type generatorSampleReader struct {
+	i *SampleReaderInfo
+	o *SampleFormat
+	r *Range
+
+	newSample func() func(n int, channel int) float64
+	sample    func(n int, channel int) float64
+
+	offset int //number of sample frames (one sample for each channel) read
+	l      []byte
+}
+
+func newSampleGenerator(info *SampleReaderInfo, sample func(n int, channel int) float64) SampleReader {
+	return newStatefulSampleGenerator(info, func() func(n int, channel int) float64 { return sample })
+}
+
+// newStatefulSampleGenerator creates a generator for sample functions which keep state between calls
+func newStatefulSampleGenerator(info *SampleReaderInfo, newSample func() func(n int, channel int) float64) SampleReader {
+	return &generatorSampleReader{i: info, o: NewSampleFormat(), newSample: newSample}
+}
+
+func (src *generatorSampleReader) Info() *SampleReaderInfo     { return src.i }
+func (src *generatorSampleReader) SampleFormat() *SampleFormat { return src.o }
+func (src *generatorSampleReader) Range() *Range               { return src.r }
+func (src *generatorSampleReader) Close() error                { return nil }
+
+func (src *generatorSampleReader) Slice(r *Range) SampleReader {
+	r = r.Intersection(&Range{Start: 0, Duration: sampleReaderDuration(src)})
+	r.parent = src.r
+
+	o := *src.o
+	return &generatorSampleReader{i: src.i, o: &o, r: r, newSample: src.newSample}
+}
+
+func (src *generatorSampleReader) ReadSampleBlock() (*SampleBlock, error) {
+	if src.sample == nil {
+		src.sample = src.newSample()
+	}
+
+	rate := src.i.SampleRate
+	channels := src.i.Channels
+
+	total := int(math.Floor(float64(sampleReaderDuration(src))*float64(rate) + 1e-3))
+
+	frames := intMax(src.o.BlockSize/(src.o.BytesPerSample()*channels), 1)
+	if src.offset+frames > total {
+		frames = total - src.offset
+	}
+
+	if frames <= 0 {
+		return nil, io.EOF
+	}
+
+	//index of the first sample in the source
+	var start int
+	if src.r != nil {
+		start = int(math.Floor(float64(src.r.AbsStart())*float64(rate) + .5))
+	}
+
+	values := make([]float32, frames*channels)
+	for i := range values {
+		values[i] = float32(src.sample(start+src.offset+i/channels, i%channels))
+	}
+
+	b := newSampleBlock(src.o, channels, values, float32(src.offset)/float32(rate), float32(frames)/float32(rate))
+
+	src.offset += frames
+
+	return b, nil
+}
+
+func (src *generatorSampleReader) Read(p []byte) (n int, err error) {
+	if len(src.l) == 0 {
+		var b *SampleBlock
+		if b, err = src.ReadSampleBlock(); err != nil {
+			return
+		}
+		src.l = b.Bytes()
+	}
+
+	n = copy(p, src.l)
+	src.l = src.l[n:]
+	return
+}
+
+// DecibelsToGain converts decibels (relative to full scale) to a linear gain
+func DecibelsToGain(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// GainToDecibels converts a linear gain to decibels (relative to full scale)
+func GainToDecibels(gain float64) float64 {
+	return 20 * math.Log10(gain)
+}
+
+// Waveform describes the shape of a tone
+type Waveform int
+
+const (
+	Sine Waveform = iota
+	Square
+	Sawtooth
+	Triangle
+)
+
+// oscillator returns the value of the waveform for the phase (0 - 1)
+func (w Waveform) oscillator(phase float64) float64 {
+	switch w {
+	case Square:
+		if phase < .5 {
+			return 1
+		}
+		return -1
+	case Sawtooth:
+		return 2*phase - 1
+	case Triangle:
+		return 1 - 4*math.Abs(phase-.5)
+	}
+	return math.Sin(2 * math.Pi * phase)
+}
+
+func phaseAt(n int, frequency float64, rate int) float64 {
+	_, frac := math.Modf(float64(n) * frequency / float64(rate))
+	return frac
+}
+
+// NewToneReader creates a SampleReader with a tone of the frequency (Hz) and amplitude (0 - 1) on all channels
+func NewToneReader(waveform Waveform, frequency, amplitude float64, info *SampleReaderInfo) SampleReader {
+	return newSampleGenerator(info, func(n, channel int) float64 {
+		return amplitude * waveform.oscillator(phaseAt(n, frequency, info.SampleRate))
+	})
+}
+
+// NewLineupToneReader creates a SampleReader with the EBU line-up tone. A 1 kHz sine at -18 dBFS.
+func NewLineupToneReader(info *SampleReaderInfo) SampleReader {
+	return NewToneReader(Sine, 1000, DecibelsToGain(-18), info)
+}
+
+// NoiseColor describes the spectrum of noise
+type NoiseColor int
+
+const (
+	// WhiteNoise has equal energy per frequency
+	WhiteNoise NoiseColor = iota
+	// PinkNoise has equal energy per octave
+	PinkNoise
+)
+
+// NewNoiseReader creates a SampleReader with noise. Each channel gets its own noise.
+// The noise is generated with a fixed seed so the output is the same for every read.
+func NewNoiseReader(noise NoiseColor, amplitude float64, info *SampleReaderInfo) SampleReader {
+	return newStatefulSampleGenerator(info, func() func(n, channel int) float64 {
+		var (
+			rnd   *rand.Rand
+			state [][7]float64
+			next  int
+		)
+
+		return func(n, channel int) float64 {
+			//restart the generator when reading from the start again (or after a slice)
+			if rnd == nil || n != next {
+				rnd = rand.New(rand.NewSource(int64(n)))
+				state = make([][7]float64, info.Channels)
+			}
+
+			if channel == info.Channels-1 {
+				next = n + 1
+			} else {
+				next = n
+			}
+
+			white := rnd.Float64()*2 - 1
+			if noise == WhiteNoise {
+				return amplitude * white
+			}
+
+			//paul kellet's refined pink noise filter
+			b := &state[channel]
+			b[0] = .99886*b[0] + white*.0555179
+			b[1] = .99332*b[1] + white*.0750759
+			b[2] = .96900*b[2] + white*.1538520
+			b[3] = .86650*b[3] + white*.3104856
+			b[4] = .55000*b[4] + white*.5329522
+			b[5] = -.7616*b[5] - white*.0168980
+			pink := b[0] + b[1] + b[2] + b[3] + b[4] + b[5] + b[6] + white*.5362
+			b[6] = white * .115926
+
+			return amplitude * pink * .11
+		}
+	})
+}
+
+// NewSweepReader creates a SampleReader with a logarithmic sine sweep from one frequency to another over the duration of info.
+// Both frequencies should be above 0. When they are the same it is a constant tone.
+func NewSweepReader(from, to, amplitude float64, info *SampleReaderInfo) (SampleReader, error) {
+	if from <= 0 || to <= 0 {
+		return nil, invalidSweepFrequencyError
+	}
+
+	k := math.Log(to / from)
+	duration := float64(info.Duration)
+
+	return newSampleGenerator(info, func(n, channel int) float64 {
+		t := float64(n) / float64(info.SampleRate)
+
+		phase := from * t
+		if k != 0 {
+			//the phase is the integral of the exponential frequency
+			phase = from * duration / k * (math.Exp(t/duration*k) - 1)
+		}
+		return amplitude * math.Sin(2*math.Pi*phase)
+	}), nil
+}
+
+// NewBeepReader creates a SampleReader with a 1 kHz beep of beepDuration at the start of every interval (in seconds).
+// With an interval of 1 there is a beep on every second of the timecode which is useful for sync tests.
+// An interval shorter than a sample gives a single beep at the start.
+func NewBeepReader(interval, beepDuration float32, amplitude float64, info *SampleReaderInfo) SampleReader {
+	period := int(math.Floor(float64(interval)*float64(info.SampleRate) + .5))
+	length := int(math.Floor(float64(beepDuration)*float64(info.SampleRate) + .5))
+
+	return newSampleGenerator(info, func(n, channel int) float64 {
+		pos := n
+		if period > 0 {
+			pos %= period
+		}
+		if pos >= length {
+			return 0
+		}
+		return amplitude * math.Sin(2*math.Pi*phaseAt(n, 1000, info.SampleRate))
+	})
+}
+
+// NewTwoPopReader creates a SampleReader which starts with a 2-pop. A single frame of 1 kHz tone at -20 dBFS followed by silence.
+func NewTwoPopReader(frameRate float32, info *SampleReaderInfo) SampleReader {
+	return NewBeepReader(info.Duration+1, 1/frameRate, DecibelsToGain(-20), info)
+}
+
+var dtmfFrequencies = map[rune][2]float64{
+	'1': {697, 1209}, '2': {697, 1336}, '3': {697, 1477}, 'A': {697, 1633},
+	'4': {770, 1209}, '5': {770, 1336}, '6': {770, 1477}, 'B': {770, 1633},
+	'7': {852, 1209}, '8': {852, 1336}, '9': {852, 1477}, 'C': {852, 1633},
+	'*': {941, 1209}, '0': {941, 1336}, '#': {941, 1477}, 'D': {941, 1633},
+}
+
+// NewDTMFReader creates a SampleReader with the DTMF tones for the digits (0-9, A-D, * and #). Each tone is followed by a pause.
+// Unknown digits are silent.
+func NewDTMFReader(digits string, toneDuration, pauseDuration float32, amplitude float64, info *SampleReaderInfo) SampleReader {
+	tones := []rune(digits)
+	tone := int(float64(toneDuration) * float64(info.SampleRate))
+	step := tone + int(float64(pauseDuration)*float64(info.SampleRate))
+
+	return newSampleGenerator(info, func(n, channel int) float64 {
+		if step <= 0 { //tone and pause are shorter than a sample
+			return 0
+		}
+
+		i := n / step
+		if i >= len(tones) || n%step >= tone {
+			return 0
+		}
+
+		f, ok := dtmfFrequencies[tones[i]]
+		if !ok {
+			return 0
+		}
+
+		return amplitude / 2 * (math.Sin(2*math.Pi*phaseAt(n, f[0], info.SampleRate)) + math.Sin(2*math.Pi*phaseAt(n, f[1], info.SampleRate)))
+	})
+}
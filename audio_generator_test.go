@@ -0,0 +1,181 @@
+package gomovie_test
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func readFloats(t *testing.T, reader gomovie.SampleReader) (out []float32) {
+	for {
+		b, err := reader.ReadSampleBlock()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, b.Floats()...)
+	}
+}
+
+func TestLineupTone(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 48000, Channels: 2, Duration: 1}
+	reader := gomovie.NewLineupToneReader(info)
+
+	samples := readFloats(t, reader)
+	if len(samples) != 96000 {
+		t.Fatalf("Expected 96000 samples but got %v", len(samples))
+	}
+
+	var peak float64
+	for _, s := range samples {
+		peak = math.Max(peak, math.Abs(float64(s)))
+	}
+
+	if db := gomovie.GainToDecibels(peak); math.Abs(db+18) > .1 {
+		t.Fatalf("Expected a peak of -18 dBFS but got %v", db)
+	}
+
+	//read as bytes in 32 bit
+	reader = gomovie.NewLineupToneReader(info)
+	reader.SampleFormat().Depth = 32
+
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b) != 96000*4 {
+		t.Fatalf("Expected %v bytes but got %v", 96000*4, len(b))
+	}
+}
+
+func TestNoiseRepeatable(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: .5}
+
+	a := readFloats(t, gomovie.NewNoiseReader(gomovie.PinkNoise, .5, info))
+	b := readFloats(t, gomovie.NewNoiseReader(gomovie.PinkNoise, .5, info))
+
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatal("Expected the same noise for every reader")
+		}
+	}
+}
+
+func TestNoiseSlicesIndependent(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 2, Duration: .5}
+	noise := gomovie.NewNoiseReader(gomovie.PinkNoise, .5, info)
+
+	expected := readFloats(t, noise.Slice(&gomovie.Range{Start: 0, Duration: .5}))
+
+	//interleaved reads of two slices don't disturb each other
+	a := noise.Slice(&gomovie.Range{Start: 0, Duration: .5})
+	b := noise.Slice(&gomovie.Range{Start: 0, Duration: .5})
+
+	var got []float32
+	for {
+		block, err := a.ReadSampleBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, block.Floats()...)
+
+		b.ReadSampleBlock()
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v samples but got %v", len(expected), len(got))
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Fatalf("Sample %v changed by reading another slice", i)
+		}
+	}
+
+	//concurrent reads of slices (run with -race)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slice := noise.Slice(&gomovie.Range{Start: 0, Duration: .5})
+			for {
+				if _, err := slice.ReadSampleBlock(); err != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBeepShortInterval(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: .5}
+
+	//an interval which rounds to 0 samples gives a single beep
+	samples := readFloats(t, gomovie.NewBeepReader(1e-5, .1, .5, info))
+
+	if len(samples) != 4000 {
+		t.Fatalf("Expected 4000 samples but got %v", len(samples))
+	}
+	if samples[1] == 0 || samples[1000] != 0 || samples[3999] != 0 {
+		t.Fatalf("Expected a single beep at the start")
+	}
+}
+
+func TestDTMF(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1}
+	samples := readFloats(t, gomovie.NewDTMFReader("12", .1, .1, .5, info))
+
+	if samples[100] == 0 || samples[1200] != 0 || samples[1700] == 0 || samples[3000] != 0 {
+		t.Fatal("Tones and pauses are not where they are expected")
+	}
+}
+
+func TestDTMFShortTones(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: .1}
+
+	//tone and pause round to 0 samples
+	for _, v := range readFloats(t, gomovie.NewDTMFReader("1", 1e-5, 1e-5, .5, info)) {
+		if v != 0 {
+			t.Fatal("Expected silence for tones shorter than a sample")
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1}
+
+	if _, err := gomovie.NewSweepReader(0, 1000, .5, info); err == nil {
+		t.Fatal("Expected an error for a frequency of 0")
+	}
+
+	sweep, err := gomovie.NewSweepReader(100, 1000, .5, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples := readFloats(t, sweep); len(samples) != 8000 || samples[1] == 0 {
+		t.Fatalf("Expected a sweep of 8000 samples")
+	}
+
+	//the same frequency gives a constant tone
+	constant, err := gomovie.NewSweepReader(1000, 1000, .5, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tone := readFloats(t, gomovie.NewToneReader(gomovie.Sine, 1000, .5, info))
+	for i, v := range readFloats(t, constant) {
+		if math.Abs(float64(v-tone[i])) > 1e-3 {
+			t.Fatalf("Expected a 1 kHz tone but got %v at %v", v, i)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package gomovie
+
+import (
+	"image/color"
+	"math"
+)
+
+// ChromaKey describes how a colored background (green or blue screen) is made transparent
+type ChromaKey struct {
+	// Color of the screen
+	Color color.Color
+
+	// Tolerance is the distance in chroma (0 - 1) from the key color below which pixels are fully transparent
+	Tolerance float64
+
+	// Softness is the distance above the tolerance over which pixels fade from transparent to opaque. Softens the edges.
+	Softness float64
+
+	// Spill (0 - 1) removes the color of the screen which is reflected on the foreground
+	Spill float64
+}
+
+// DefaultGreenScreen is a ChromaKey for a chroma key green screen
+var DefaultGreenScreen = ChromaKey{Color: color.RGBA{0, 177, 64, 255}, Tolerance: .12, Softness: .1, Spill: 1}
+
+// DefaultBlueScreen is a ChromaKey for a chroma key blue screen
+var DefaultBlueScreen = ChromaKey{Color: color.RGBA{0, 71, 187, 255}, Tolerance: .12, Softness: .1, Spill: 1}
+
+// chroma returns the Cb and Cr (Rec. 601) of the color between -.5 and .5
+func chroma(r, g, b float64) (float64, float64) {
+	cb := (-.168736*r - .331264*g + .5*b) / 255
+	cr := (.5*r - .418688*g - .081312*b) / 255
+	return cb, cr
+}
+
+// keyAlpha returns the alpha (0 - 1) for a chroma distance
+func (k ChromaKey) keyAlpha(distance float64) float64 {
+	if distance <= k.Tolerance {
+		return 0
+	}
+	if k.Softness <= 0 || distance >= k.Tolerance+k.Softness {
+		return 1
+	}
+
+	//smoothstep
+	p := (distance - k.Tolerance) / k.Softness
+	return p * p * (3 - 2*p)
+}
+
+// NewChromaKeyTransform creates a FrameTransform which makes the pixels close to the key color transparent by lowering
+// their alpha. Combine it with a Compositor to put the keyed frames over a background.
+func NewChromaKeyTransform(k ChromaKey) FrameTransform {
+	kr, kg, kb, _ := k.Color.RGBA()
+	key := [3]float64{float64(kr >> 8), float64(kg >> 8), float64(kb >> 8)}
+	kcb, kcr := chroma(key[0], key[1], key[2])
+
+	//the channel of the screen color is the one which spills
+	spill := 0
+	for c := range key {
+		if key[c] > key[spill] {
+			spill = c
+		}
+	}
+	o1, o2 := (spill+1)%3, (spill+2)%3
+
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			for i := 0; i+4 <= len(f.Data); i += 4 {
+				p := f.Data[i : i+4]
+				if p[3] == 0 {
+					continue
+				}
+
+				cb, cr := chroma(float64(p[0]), float64(p[1]), float64(p[2]))
+				a := k.keyAlpha(math.Hypot(cb-kcb, cr-kcr))
+
+				if a == 0 {
+					p[3] = 0
+					continue
+				}
+
+				p[3] = clampUint8(float32(float64(p[3]) * a))
+
+				//limit the screen channel to the strongest other channel
+				if limit := intMax(int(p[o1]), int(p[o2])); k.Spill > 0 && int(p[spill]) > limit {
+					v := float64(p[spill]) - k.Spill*float64(int(p[spill])-limit)
+					p[spill] = clampUint8(float32(v))
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package gomovie_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestChromaKeyTransform(t *testing.T) {
+	data := []byte{
+		0, 177, 64, 255, //the screen
+		200, 150, 120, 255, //skin
+		150, 190, 140, 255, //green spill on the edge
+		0, 0, 0, 0,
+	}
+	f := &gomovie.Frame{Data: data, Width: 4, Height: 1}
+
+	gomovie.NewChromaKeyTransform(gomovie.DefaultGreenScreen).Transform(f)
+
+	if f.Data[3] != 0 {
+		t.Fatalf("Expected the screen to be transparent but got %v", f.Data[:4])
+	}
+	if f.Data[7] != 255 || f.Data[4] != 200 || f.Data[5] != 150 {
+		t.Fatalf("Expected skin to be unchanged but got %v", f.Data[4:8])
+	}
+	if f.Data[11] != 255 || f.Data[9] > f.Data[8] {
+		t.Fatalf("Expected the green spill to be removed but got %v", f.Data[8:12])
+	}
+}
+
+func TestKeyOver(t *testing.T) {
+	screen := colorClip(color.RGBA{0, 177, 64, 255}, 1)
+	background := colorClip(color.RGBA{255, 0, 0, 255}, 1)
+
+	frames := readFrames(t, gomovie.KeyOver(screen, background, gomovie.DefaultGreenScreen))
+	if len(frames) != 25 {
+		t.Fatalf("Expected 25 frames but got %v", len(frames))
+	}
+
+	if p := frames[10].Data[:4]; p[0] != 255 || p[1] != 0 || p[3] != 255 {
+		t.Fatalf("Expected the background through the keyed screen but got %v", p)
+	}
+}
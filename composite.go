@@ -0,0 +1,302 @@
+package gomovie
+
+import (
+	"image/color"
+	"io"
+	"math"
+)
+
+// MatteMode is the way the frames of a matte are turned into the alpha of a layer
+type MatteMode int
+
+const (
+	// AlphaMatte uses the alpha of the matte
+	AlphaMatte MatteMode = iota
+
+	// InvertedAlphaMatte shows the layer where the matte is transparent
+	InvertedAlphaMatte
+
+	// LumaMatte uses the luma of the matte. White is opaque, black is transparent.
+	LumaMatte
+
+	// InvertedLumaMatte shows the layer where the matte is black
+	InvertedLumaMatte
+)
+
+// value returns the alpha (0 - 255) of the matte pixel
+func (m MatteMode) value(p []byte) uint32 {
+	switch m {
+	case InvertedAlphaMatte:
+		return 255 - uint32(p[3])
+	case LumaMatte:
+		return uint32(luma(p))
+	case InvertedLumaMatte:
+		return 255 - uint32(luma(p))
+	default:
+		return uint32(p[3])
+	}
+}
+
+// CompositeLayer describes a FrameReader in a Compositor
+type CompositeLayer struct {
+	Reader FrameReader
+
+	// Matte is an optional FrameReader which masks the layer. It is read in sync with the reader and resized to the
+	// size of the layer. Any FrameReader can be a matte. Once the matte ends it is treated as a fully transparent frame.
+	Matte     FrameReader
+	MatteMode MatteMode
+
+	// X and Y are the position of the top left corner of the layer in the composite. Layers are not resized.
+	X, Y int
+
+	// Opacity (0 - 1) of the layer at the time in the composite. Empty keyframes are fully opaque.
+	Opacity Keyframes
+
+	// Start is the time in seconds in the composite at which the layer starts
+	Start float32
+
+	Hidden bool
+}
+
+// NewCompositeLayer creates an opaque CompositeLayer at the top left which starts at the beginning of the composite
+func NewCompositeLayer(reader FrameReader) *CompositeLayer {
+	return &CompositeLayer{Reader: reader}
+}
+
+// layerInput reads the frames of a layer at the frame rate of the composite. Frames are repeated or skipped when the frame rates differ.
+type layerInput struct {
+	reader   FrameReader
+	duration float32
+
+	current, next *Frame
+	done          bool
+}
+
+func newLayerInput(reader FrameReader) *layerInput {
+	return &layerInput{reader: reader, duration: frameReaderDuration(reader)}
+}
+
+// frameAt returns the last frame at or before t. Returns nil when there is no frame at t.
+func (in *layerInput) frameAt(t float32) (*Frame, error) {
+	if t >= in.duration {
+		return nil, nil
+	}
+
+	for !in.done {
+		if in.next == nil {
+			f, err := in.reader.ReadFrame()
+			if err == io.EOF {
+				in.done = true
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			in.next = f
+		}
+
+		if in.next.Time > t+1e-4 {
+			break
+		}
+		in.current, in.next = in.next, nil
+	}
+
+	return in.current, nil
+}
+
+// applyMatte returns a copy of the data of f with its alpha multiplied by the matte. A nil matte is fully transparent.
+func applyMatte(f *Frame, matte *Frame, mode MatteMode) []byte {
+	data := make([]byte, len(f.Data))
+	copy(data, f.Data)
+
+	var m []byte
+	if matte != nil {
+		m = matte.Data
+		if matte.Width != f.Width || matte.Height != f.Height {
+			m = resizeRGBA(matte.Data, matte.Width, matte.Height, f.Width, f.Height, Bilinear)
+		}
+	} else {
+		//a transparent black frame
+		m = make([]byte, len(f.Data))
+	}
+
+	for i := 3; i < len(data); i += 4 {
+		data[i] = uint8(uint32(data[i]) * mode.value(m[i-3:i+1]) / 255)
+	}
+
+	return data
+}
+
+// Compositor draws the layers over each other into a single FrameReader. The first layer is at the bottom.
+// Layers are drawn using their alpha so transparent parts (for example from a chroma key or a matte) show the layers below.
+// implements the FrameReader interface.
+type Compositor struct {
+	Layers []*CompositeLayer
+
+	// Background is drawn below the layers. Black by NewCompositor. Nil leaves the composite transparent.
+	Background color.Color
+
+	i *FrameReaderInfo
+	r *Range
+
+	frameIndex int
+	frames     map[*CompositeLayer]*layerInput
+	mattes     map[*CompositeLayer]*layerInput
+	l          []byte
+}
+
+// NewCompositor creates a Compositor for the layers. The size and frame rate are taken from the first layer.
+// The duration is the end of the last layer. The info can be changed before reading.
+func NewCompositor(layers ...*CompositeLayer) *Compositor {
+	c := &Compositor{Background: color.Black, i: new(FrameReaderInfo)}
+	for _, l := range layers {
+		c.AddLayer(l)
+	}
+	return c
+}
+
+// AddLayer adds the layer on top and updates the info of the compositor
+func (c *Compositor) AddLayer(l *CompositeLayer) *Compositor {
+	c.Layers = append(c.Layers, l)
+
+	info := l.Reader.Info()
+	if c.i.Width == 0 || c.i.Height == 0 {
+		c.i.Width, c.i.Height = info.Width, info.Height
+	}
+	if c.i.FrameRate == 0 {
+		c.i.FrameRate = info.FrameRate
+	}
+	c.i.Duration = float32Max(c.i.Duration, l.Start+frameReaderDuration(l.Reader))
+
+	return c
+}
+
+func (c *Compositor) Info() *FrameReaderInfo { return c.i }
+func (c *Compositor) Range() *Range          { return c.r }
+
+// Close closes the readers and mattes of all the layers
+func (c *Compositor) Close() (err error) {
+	for _, l := range c.Layers {
+		if err = l.Reader.Close(); err != nil {
+			return
+		}
+		if l.Matte != nil {
+			if err = l.Matte.Close(); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Slice returns a new Compositor for the range. The layers and their mattes are sliced and moved so they keep their position in the composite.
+func (c *Compositor) Slice(r *Range) FrameReader {
+	r = r.Intersection(&Range{Start: 0, Duration: frameReaderDuration(c)})
+	r.parent = c.r
+
+	s := &Compositor{Background: c.Background, i: c.i, r: r}
+
+	for _, l := range c.Layers {
+		lc := *l
+
+		//every slice gets its own layer and matte readers
+		lr := &Range{Start: 0, Duration: frameReaderDuration(l.Reader)}
+		if r.Start > l.Start {
+			lr = &Range{Start: r.Start - l.Start, Duration: r.Duration}
+			lc.Start = 0
+		} else {
+			lc.Start = l.Start - r.Start
+		}
+
+		lc.Reader = l.Reader.Slice(lr)
+		if l.Matte != nil {
+			lc.Matte = l.Matte.Slice(lr)
+		}
+
+		s.Layers = append(s.Layers, &lc)
+	}
+
+	return s
+}
+
+// ReadFrame composites the next frame of all the visible layers
+func (c *Compositor) ReadFrame() (*Frame, error) {
+	if c.frames == nil {
+		c.frames = make(map[*CompositeLayer]*layerInput)
+		c.mattes = make(map[*CompositeLayer]*layerInput)
+		for _, l := range c.Layers {
+			c.frames[l] = newLayerInput(l.Reader)
+			if l.Matte != nil {
+				c.mattes[l] = newLayerInput(l.Matte)
+			}
+		}
+	}
+
+	count := int(math.Floor(float64(frameReaderDuration(c)*c.i.FrameRate) + 1e-3))
+	if c.frameIndex >= count {
+		return nil, io.EOF
+	}
+
+	w, h := c.i.Width, c.i.Height
+	t := float32(c.frameIndex) / c.i.FrameRate
+	f := &Frame{Data: make([]byte, w*h*4), Width: w, Height: h, Index: c.frameIndex, Time: t}
+	c.frameIndex++
+
+	//the opacity keyframes use the time in the source so they don't move after a Slice
+	source := t
+	if c.r != nil {
+		source += c.r.AbsStart()
+	}
+
+	if c.Background != nil {
+		fillRGBA(f.Data, color.NRGBAModel.Convert(c.Background).(color.NRGBA))
+	}
+
+	for _, l := range c.Layers {
+		if l.Hidden || t < l.Start {
+			continue
+		}
+
+		lf, err := c.frames[l].frameAt(t - l.Start)
+		if err != nil {
+			return nil, err
+		}
+		if lf == nil {
+			continue
+		}
+
+		data := lf.Data
+		if l.Matte != nil {
+			mf, err := c.mattes[l].frameAt(t - l.Start)
+			if err != nil {
+				return nil, err
+			}
+			data = applyMatte(lf, mf, l.MatteMode)
+		}
+
+		opacity := clampUint8(float32(keyframesOr(l.Opacity, source, 1) * 255))
+		drawOverRGBA(f.Data, w, h, data, lf.Width, lf.Height, l.X, l.Y, uint32(opacity))
+	}
+
+	return f, nil
+}
+
+func (c *Compositor) Read(p []byte) (n int, err error) {
+	if len(c.l) == 0 {
+		var f *Frame
+		if f, err = c.ReadFrame(); err != nil {
+			return
+		}
+		c.l = f.Data
+	}
+
+	n = copy(p, c.l)
+	c.l = c.l[n:]
+	return
+}
+
+// KeyOver removes the screen of the foreground with the chroma key and puts it over the background
+func KeyOver(foreground, background FrameReader, k ChromaKey) *Compositor {
+	keyed := NewFrameTransformer(foreground).AddTransform(NewChromaKeyTransform(k))
+	return NewCompositor(NewCompositeLayer(background), NewCompositeLayer(keyed))
+}
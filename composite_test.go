@@ -0,0 +1,92 @@
+package gomovie_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestCompositor(t *testing.T) {
+	base := colorClip(color.Black, 2)
+
+	layer := gomovie.NewCompositeLayer(gomovie.NewColorClip(color.White, &gomovie.FrameReaderInfo{Width: 4, Height: 4, FrameRate: 10, Duration: 1}))
+	layer.X, layer.Y = 2, 2
+	layer.Start = .5
+	layer.Opacity = gomovie.Constant(.5)
+
+	c := gomovie.NewCompositor(gomovie.NewCompositeLayer(base), layer)
+	if info := c.Info(); info.Width != 8 || info.FrameRate != 25 || info.Duration != 2 {
+		t.Fatalf("Unexpected info %v", info)
+	}
+
+	frames := readFrames(t, c)
+	if len(frames) != 50 {
+		t.Fatalf("Expected 50 frames but got %v", len(frames))
+	}
+
+	pixel := func(f *gomovie.Frame, x, y int) []byte {
+		i := (y*f.Width + x) * 4
+		return f.Data[i : i+4]
+	}
+
+	if p := pixel(frames[5], 3, 3); p[0] != 0 {
+		t.Fatalf("Expected the layer to start later but got %v", p)
+	}
+	if p := pixel(frames[20], 3, 3); p[0] < 126 || p[0] > 129 {
+		t.Fatalf("Expected the layer at half opacity but got %v", p)
+	}
+	if p := pixel(frames[20], 1, 1); p[0] != 0 {
+		t.Fatalf("Expected the layer at its position but got %v", p)
+	}
+	if p := pixel(frames[45], 3, 3); p[0] != 0 {
+		t.Fatalf("Expected the layer to end but got %v", p)
+	}
+
+	sliced := readFrames(t, c.Slice(&gomovie.Range{Start: 1, Duration: 1}))
+	if len(sliced) != 25 || pixel(sliced[0], 3, 3)[0] == 0 {
+		t.Fatalf("Expected the slice to keep the layer in place")
+	}
+}
+
+func TestCompositorSlices(t *testing.T) {
+	layer := gomovie.NewCompositeLayer(colorClip(color.White, 1))
+	layer.Start = .5
+
+	c := gomovie.NewCompositor(gomovie.NewCompositeLayer(colorClip(color.Black, 2)), layer)
+
+	//both slices start before the layer so each needs its own reader of the layer
+	for i := 0; i < 2; i++ {
+		frames := readFrames(t, c.Slice(&gomovie.Range{Start: .25, Duration: 1}))
+		if len(frames) != 25 {
+			t.Fatalf("Expected 25 frames but got %v", len(frames))
+		}
+		if frames[0].Data[0] != 0 || frames[20].Data[0] != 255 {
+			t.Fatalf("Expected the layer after .25 seconds in slice %v", i)
+		}
+	}
+}
+
+func TestCompositorMatte(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 8, Height: 8, FrameRate: 25, Duration: 1}
+
+	for _, test := range []struct {
+		mode gomovie.MatteMode
+		red  uint8
+	}{
+		{gomovie.LumaMatte, 0},
+		{gomovie.InvertedLumaMatte, 255},
+		{gomovie.AlphaMatte, 255},
+		{gomovie.InvertedAlphaMatte, 0},
+	} {
+		//a black but opaque matte at a different size
+		layer := gomovie.NewCompositeLayer(gomovie.NewColorClip(color.RGBA{255, 0, 0, 255}, info))
+		layer.Matte = gomovie.NewColorClip(color.Black, &gomovie.FrameReaderInfo{Width: 2, Height: 2, FrameRate: 25, Duration: 1})
+		layer.MatteMode = test.mode
+
+		frames := readFrames(t, gomovie.NewCompositor(gomovie.NewCompositeLayer(colorClip(color.Black, 1)), layer))
+		if red := frames[0].Data[0]; red != test.red {
+			t.Fatalf("Expected red %v for matte mode %v but got %v", test.red, test.mode, red)
+		}
+	}
+}
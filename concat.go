@@ -1,16 +1,15 @@
 package gomovie
 
 import (
-	"image"
-	"image/draw"
 	"io"
 )
 
 type frameReaderList struct {
 	readers []FrameReader
 
-	r *Range
-	i *FrameReaderInfo
+	r   *Range
+	i   *FrameReaderInfo
+	fit Fit
 
 	index int
 	l     []byte
@@ -20,45 +19,54 @@ func (src *frameReaderList) Info() *FrameReaderInfo { return src.i }
 func (src *frameReaderList) Range() *Range          { return src.r }
 
 func (src *frameReaderList) fitInImg(f *Frame) []byte {
-	srcImg := f.ToNRGBAImage()
-	dstImg := image.NewNRGBA(image.Rect(0, 0, src.i.Width, src.i.Height))
-	draw.Draw(dstImg, srcImg.Bounds(), srcImg, image.Point{X: 0, Y: 0}, draw.Over)
-	return dstImg.Pix
+	return src.fit.apply(f.Data, f.Width, f.Height, src.i.Width, src.i.Height)
 }
 
 func (src *frameReaderList) Slice(r *Range) FrameReader {
-	readers := src.readers
-
-	var (
-		e int
-		t float32
-	)
+	durations := make([]float32, len(src.readers))
+	for x, reader := range src.readers {
+		durations[x] = frameReaderDuration(reader)
+	}
 
-	for len(readers) > 0 {
-		if readers[e].Range() != nil {
-			t += readers[e].Range().Duration
-		} else {
-			t += readers[e].Info().Duration
-		}
+	parts, ranges := sliceParts(durations, r)
 
-		if r.Start > t { //range does NOT start in current item
-			readers = readers[1:]
-		} else {
-			if t > r.Duration {
-				break
-			}
-			e++
-		}
+	readers := make([]FrameReader, len(parts))
+	for x, p := range parts {
+		readers[x] = src.readers[p].Slice(ranges[x])
 	}
 
-	readers = readers[:e]
-
 	return &frameReaderList{
 		readers: readers,
+		r:       sliceRange(durations, r, src.r),
+		i:       src.i,
+		fit:     src.fit,
+	}
+}
 
-		//range
-		r: &Range{parent: src.r, Start: r.Start, Duration: 0.},
+//sliceParts returns the index of each part (with the given durations) which overlaps the range and the range within that part
+func sliceParts(durations []float32, r *Range) (parts []int, ranges []*Range) {
+	var t float32
+	for x, d := range durations {
+		p := r.Intersection(&Range{Start: t, Duration: d})
+		if p.Duration > 0 {
+			parts = append(parts, x)
+			ranges = append(ranges, &Range{Start: p.Start - t, Duration: p.Duration})
+		}
+		t += d
 	}
+	return
+}
+
+//sliceRange returns the range of a slice of the parts limited to the total duration
+func sliceRange(durations []float32, r *Range, parent *Range) *Range {
+	var total float32
+	for _, d := range durations {
+		total += d
+	}
+
+	sr := r.Intersection(&Range{Start: 0, Duration: total})
+	sr.parent = parent
+	return sr
 }
 
 //Close closes all the readers
@@ -118,7 +126,7 @@ func (src *frameReaderList) ReadFrame() (f *Frame, err error) {
 		}
 
 		//if frame size is not the same as info size
-		//align the frame within containing frame
+		//fit the frame within containing frame
 		if rf.Width != src.i.Width || rf.Height != src.i.Height {
 			f.Data = src.fitInImg(rf)
 		} else {
@@ -132,7 +140,7 @@ func (src *frameReaderList) ReadFrame() (f *Frame, err error) {
 	return nil, io.EOF
 }
 
-func concatFrameReaders(readers ...FrameReader) FrameReader {
+func concatFrameReaders(fit Fit, readers ...FrameReader) FrameReader {
 	sumInfo := new(FrameReaderInfo)
 
 	for _, reader := range readers {
@@ -143,7 +151,7 @@ func concatFrameReaders(readers ...FrameReader) FrameReader {
 		sumInfo.FrameRate = float32Max(info.FrameRate, sumInfo.FrameRate)
 	}
 
-	return &frameReaderList{readers: readers, i: sumInfo}
+	return &frameReaderList{readers: readers, i: sumInfo, fit: fit}
 }
 
 type sampleReaderList struct {
@@ -153,6 +161,9 @@ type sampleReaderList struct {
 	o *SampleFormat
 	i *SampleReaderInfo
 
+	offset float32 //start time of the current reader
+	end    float32 //end time of the last block
+
 	l []byte
 }
 
@@ -161,39 +172,25 @@ func (src *sampleReaderList) SampleFormat() *SampleFormat { return src.o }
 func (src *sampleReaderList) Range() *Range               { return src.r }
 
 func (src *sampleReaderList) Slice(r *Range) SampleReader {
-	readers := src.readers
-
-	var (
-		e int
-		t float32
-	)
+	durations := make([]float32, len(src.readers))
+	for x, reader := range src.readers {
+		durations[x] = sampleReaderDuration(reader)
+	}
 
-	for len(readers) > 0 {
-		if readers[e].Range() != nil {
-			t += readers[e].Range().Duration
-		} else {
-			t += readers[e].Info().Duration
-		}
+	parts, ranges := sliceParts(durations, r)
 
-		if r.Start > t { //range does NOT start in current item
-			readers = readers[1:]
-		} else {
-			if t > r.Duration {
-				break
-			}
-			e++
-		}
+	readers := make([]SampleReader, len(parts))
+	for x, p := range parts {
+		readers[x] = src.readers[p].Slice(ranges[x])
 	}
 
-	readers = readers[:e]
+	o := *src.o
 
 	return &sampleReaderList{
 		readers: readers,
-
-		//range
-		r: &Range{parent: src.r, Start: r.Start, Duration: 0.},
-
-		i: src.i,
+		r:       sliceRange(durations, r, src.r),
+		o:       &o,
+		i:       src.i,
 	}
 }
 
@@ -236,10 +233,17 @@ func (src *sampleReaderList) ReadSampleBlock() (b *SampleBlock, err error) {
 		*so = *src.o //pass the sample format to the sub reader
 
 		b, err = r.ReadSampleBlock()
-		if err == nil || err != io.EOF {
+		if err == nil {
+			//the time of the block is relative to the sub reader
+			b.Time += src.offset
+			src.end = b.Time + b.Duration
+			return
+		}
+		if err != io.EOF {
 			return
 		}
 		src.readers = src.readers[1:]
+		src.offset = src.end
 	}
 	return nil, io.EOF
 }
@@ -254,18 +258,39 @@ func concatSampleReaders(readers ...SampleReader) SampleReader {
 		sumInfo.Channels = intMax(info.Channels, sumInfo.Channels)
 	}
 
+	//convert the readers which don't match so they don't play at the wrong speed
+	for x, reader := range readers {
+		readers[x] = convertSampleReader(reader, sumInfo.SampleRate, sumInfo.Channels)
+	}
+
 	return &sampleReaderList{readers: readers, o: NewSampleFormat(), i: sumInfo}
 }
 
+//Concat joins the given FrameReaders, SampleReaders and Videos into a single Video.
+//A Fit can be passed along with the readers to decide how frames with a different size are placed. DefaultFit is used otherwise.
 func Concat(readers ...interface{}) *Video {
+	fit := DefaultFit
+
+	//filter out the options
+	sources := make([]interface{}, 0, len(readers))
+	for _, reader := range readers {
+		if f, ok := reader.(Fit); ok {
+			fit = f
+		} else {
+			sources = append(sources, reader)
+		}
+	}
+	readers = sources
 
 	frameReaders := make([]FrameReader, len(readers))
 	sampleReaders := make([]SampleReader, len(readers))
 
+	subtitles := make([]*Subtitles, len(readers))
+
 	var (
-		sumFrameInfo          FrameReaderInfo
-		sumSampleInfo         SampleReaderInfo
-		hasSamples, hasFrames bool
+		sumFrameInfo                        FrameReaderInfo
+		sumSampleInfo                       SampleReaderInfo
+		hasSamples, hasFrames, hasSubtitles bool
 	)
 
 	addSampleReader := func(index int, reader SampleReader) {
@@ -293,6 +318,10 @@ func Concat(readers ...interface{}) *Video {
 		case SampleReader:
 			addSampleReader(index, r)
 		case *Video:
+			if r.Subtitles != nil {
+				subtitles[index] = r.Subtitles
+				hasSubtitles = true
+			}
 			if r.SampleReader != nil {
 				addSampleReader(index, r.SampleReader)
 			}
@@ -317,16 +346,42 @@ func Concat(readers ...interface{}) *Video {
 	vid := new(Video)
 
 	if hasFrames {
-		vid.FrameReader = concatFrameReaders(frameReaders...)
+		vid.FrameReader = concatFrameReaders(fit, frameReaders...)
 	}
 
 	if hasSamples {
 		vid.SampleReader = concatSampleReaders(sampleReaders...)
 	}
 
+	if hasSubtitles {
+		durations := make([]float32, len(readers))
+		for x := range durations {
+			if frameReaders[x] != nil {
+				durations[x] = frameReaderDuration(frameReaders[x])
+			} else if sampleReaders[x] != nil {
+				durations[x] = sampleReaderDuration(sampleReaders[x])
+			}
+		}
+		vid.Subtitles = concatSubtitles(subtitles, durations)
+	}
+
 	return vid
 }
 
+func frameReaderDuration(r FrameReader) float32 {
+	if r.Range() != nil {
+		return r.Range().Duration
+	}
+	return r.Info().Duration
+}
+
+func sampleReaderDuration(r SampleReader) float32 {
+	if r.Range() != nil {
+		return r.Range().Duration
+	}
+	return r.Info().Duration
+}
+
 func intMax(i1, i2 int) int {
 	if i2 > i1 {
 		return i2
@@ -0,0 +1,186 @@
+package gomovie
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// cropRGBA copies the rect out of the w x h rgba data. The rect should be within the bounds.
+func cropRGBA(src []byte, w int, rect image.Rectangle) []byte {
+	cw, ch := rect.Dx(), rect.Dy()
+	dst := make([]byte, cw*ch*4)
+	for y := 0; y < ch; y++ {
+		s := ((rect.Min.Y+y)*w + rect.Min.X) * 4
+		copy(dst[y*cw*4:(y+1)*cw*4], src[s:s+cw*4])
+	}
+	return dst
+}
+
+// clampRect moves the rect inside the bounds. The size is limited to the size of the bounds and is at least 1x1.
+func clampRect(rect, bounds image.Rectangle) image.Rectangle {
+	rect = rect.Canon()
+
+	w := intMin(intMax(rect.Dx(), 1), bounds.Dx())
+	h := intMin(intMax(rect.Dy(), 1), bounds.Dy())
+
+	x := intMin(intMax(rect.Min.X, bounds.Min.X), bounds.Max.X-w)
+	y := intMin(intMax(rect.Min.Y, bounds.Min.Y), bounds.Max.Y-h)
+
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// NewCropTransform creates a FrameTransform which crops each frame to rect.
+// The rect is clipped to the bounds of the frame. A rect which is completely outside the frame is moved inside so the frame never gets empty.
+func NewCropTransform(rect image.Rectangle) FrameTransform {
+	return FrameTransform{
+		Resize: func(f *Frame) {
+			bounds := image.Rect(0, 0, f.Width, f.Height)
+
+			r := rect.Intersect(bounds)
+			if r.Empty() {
+				r = clampRect(rect, bounds)
+			}
+
+			if f.Data != nil {
+				f.Data = cropRGBA(f.Data, f.Width, r)
+			}
+
+			f.Width = r.Dx()
+			f.Height = r.Dy()
+		},
+	}
+}
+
+// NewAnimatedCropTransform creates a FrameTransform which crops each frame to the rect returned by fn for the time of the frame.
+// Every cropped area is scaled to width x height so the output size stays the same. Useful for pan and zoom effects.
+func NewAnimatedCropTransform(width, height int, filter ResizeFilter, fn func(t float32) image.Rectangle) FrameTransform {
+	fit := Fit{Mode: FitStretch, Filter: filter}
+
+	return FrameTransform{
+		Resize: func(f *Frame) {
+			if f.Data != nil {
+				r := fn(f.Time).Intersect(image.Rect(0, 0, f.Width, f.Height))
+				if r.Empty() {
+					f.Data = make([]byte, width*height*4)
+				} else {
+					f.Data = fit.apply(cropRGBA(f.Data, f.Width, r), r.Dx(), r.Dy(), width, height)
+				}
+			}
+
+			f.Width = width
+			f.Height = height
+		},
+	}
+}
+
+// NewPadTransform creates a FrameTransform which adds borders with the background color so the frame gets the given aspect ratio (width / height).
+// The frame itself is centered and not scaled.
+func NewPadTransform(aspect float64, background color.Color) FrameTransform {
+	fit := Fit{Mode: FitCenter, Background: background}
+
+	return FrameTransform{
+		Resize: func(f *Frame) {
+			w, h := f.Width, f.Height
+
+			if float64(w)/float64(h) < aspect {
+				w = int(math.Ceil(float64(h) * aspect))
+			} else {
+				h = int(math.Ceil(float64(w) / aspect))
+			}
+
+			if f.Data != nil {
+				f.Data = fit.apply(f.Data, f.Width, f.Height, w, h)
+			}
+
+			f.Width = w
+			f.Height = h
+		},
+	}
+}
+
+// DetectCrop samples the given number of frames evenly spread over the reader and returns the smallest rectangle
+// which contains all the non black content. A row or column is considered black when its average luma is
+// not above threshold (24 is a good value). The edges are rounded to even values.
+// When every sampled frame is black the full frame is returned.
+func DetectCrop(reader FrameReader, samples int, threshold uint8) (image.Rectangle, error) {
+	info := reader.Info()
+
+	duration := info.Duration
+	if reader.Range() != nil {
+		duration = reader.Range().Duration
+	}
+
+	frameDuration := 1 / info.FrameRate
+
+	var crop image.Rectangle
+
+	for i := 0; i < samples; i++ {
+		t := duration * (float32(i) + .5) / float32(samples)
+
+		sample := reader.Slice(&Range{Start: t, Duration: 2 * frameDuration})
+		f, err := sample.ReadFrame()
+		sample.Close()
+
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+
+		crop = crop.Union(detectFrameCrop(f, threshold))
+	}
+
+	if crop.Empty() {
+		return image.Rect(0, 0, info.Width, info.Height), nil
+	}
+
+	//round inwards to even values (most codecs require this for yuv420p)
+	crop.Min.X += crop.Min.X % 2
+	crop.Min.Y += crop.Min.Y % 2
+	crop.Max.X -= crop.Dx() % 2
+	crop.Max.Y -= crop.Dy() % 2
+
+	return crop, nil
+}
+
+// detectFrameCrop returns the area of the frame which is not a black border
+func detectFrameCrop(f *Frame, threshold uint8) image.Rectangle {
+	rowBright := func(y int) bool {
+		var sum int
+		for x := 0; x < f.Width; x++ {
+			sum += int(luma(f.Data[(y*f.Width+x)*4:]))
+		}
+		return sum > int(threshold)*f.Width
+	}
+
+	colBright := func(x, y0, y1 int) bool {
+		var sum int
+		for y := y0; y < y1; y++ {
+			sum += int(luma(f.Data[(y*f.Width+x)*4:]))
+		}
+		return sum > int(threshold)*(y1-y0)
+	}
+
+	var r image.Rectangle
+
+	for r.Min.Y = 0; r.Min.Y < f.Height && !rowBright(r.Min.Y); r.Min.Y++ {
+	}
+
+	if r.Min.Y == f.Height { //completely black
+		return image.Rectangle{}
+	}
+
+	for r.Max.Y = f.Height; !rowBright(r.Max.Y - 1); r.Max.Y-- {
+	}
+
+	for r.Min.X = 0; r.Min.X < f.Width && !colBright(r.Min.X, r.Min.Y, r.Max.Y); r.Min.X++ {
+	}
+
+	if r.Min.X == f.Width {
+		return image.Rectangle{}
+	}
+
+	for r.Max.X = f.Width; !colBright(r.Max.X-1, r.Min.Y, r.Max.Y); r.Max.X-- {
+	}
+
+	return r
+}
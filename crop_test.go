@@ -0,0 +1,62 @@
+package gomovie_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestDetectCrop(t *testing.T) {
+	//letterboxed content with 6 pixels at the top and bottom which fades in from black in the first second.
+	//When a Slice would start at 0 every sample is black and the full frame is returned.
+	size := &gomovie.FrameReaderInfo{Width: 64, Height: 36, FrameRate: 25, Duration: 2}
+	clip := gomovie.FadeFrames(gomovie.NewColorClip(color.NRGBA{200, 200, 200, 255}, size), 1, 0, gomovie.FadeLinear, color.Black)
+	reader := clip.AddTransform(gomovie.NewPadTransform(64./48, color.Black))
+
+	rect, err := gomovie.DetectCrop(reader, 5, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rect != image.Rect(0, 6, 64, 42) {
+		t.Fatalf("Unexpected crop %v", rect)
+	}
+
+	transformer := gomovie.NewFrameTransformer(reader)
+	transformer.AddTransform(gomovie.NewCropTransform(rect))
+	transformer.AddTransform(gomovie.NewPadTransform(1, color.Black))
+
+	info := transformer.Info()
+	if info.Width != 64 || info.Height != 64 {
+		t.Fatalf("Expected a square info but got %vx%v", info.Width, info.Height)
+	}
+
+	cropped, err := transformer.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cropped.Width != 64 || cropped.Height != 64 || len(cropped.Data) != 64*64*4 {
+		t.Fatalf("Unexpected frame size %vx%v", cropped.Width, cropped.Height)
+	}
+}
+
+func TestCropTransformOutside(t *testing.T) {
+	transformer := gomovie.NewFrameTransformer(colorClip(color.White, 1))
+	transformer.AddTransform(gomovie.NewCropTransform(image.Rect(20, 20, 24, 26)))
+
+	f, err := transformer.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Width != 4 || f.Height != 6 || len(f.Data) != 4*6*4 {
+		t.Fatalf("Expected the rect to be moved inside the frame but got %vx%v", f.Width, f.Height)
+	}
+
+	if f.Data[0] != 255 {
+		t.Fatalf("Expected the content of the frame but got %v", f.Data[:4])
+	}
+}
@@ -0,0 +1,259 @@
+package gomovie
+
+import (
+	"io"
+	"math"
+)
+
+// intervalCollector turns a sequence of matching moments into ranges of at least a minimum duration
+type intervalCollector struct {
+	minDuration float32
+
+	active bool
+	start  float32
+	end    float32
+
+	ranges []Range
+}
+
+// add marks the moment from t to t+duration as matching or not
+func (c *intervalCollector) add(t, duration float32, match bool) {
+	if match {
+		if !c.active {
+			c.active = true
+			c.start = t
+		}
+		c.end = t + duration
+		return
+	}
+
+	c.close()
+}
+
+func (c *intervalCollector) close() {
+	if c.active && c.end-c.start >= c.minDuration {
+		c.ranges = append(c.ranges, Range{Start: c.start, Duration: c.end - c.start})
+	}
+	c.active = false
+}
+
+// DetectSilence returns the ranges where all channels stay below the threshold (dBFS) for at least minDuration seconds.
+// The ranges are relative to the start of the reader so they can be passed to Slice.
+func DetectSilence(reader SampleReader, threshold float64, minDuration float32) ([]Range, error) {
+	info := reader.Info()
+	input := newFloatFrameReader(reader)
+	limit := DecibelsToGain(threshold)
+
+	c := &intervalCollector{minDuration: minDuration}
+	step := 1 / float32(info.SampleRate)
+
+	var n int
+	for {
+		frames, err := input.read(1024)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i+info.Channels <= len(frames); i += info.Channels {
+			c.add(float32(float64(n)/float64(info.SampleRate)), step, framePeak(frames[i:i+info.Channels]) < limit)
+			n++
+		}
+	}
+
+	c.close()
+	return c.ranges, nil
+}
+
+// isBlackFrame is true when at least ratio of the pixels have a luma below or equal to the threshold
+func isBlackFrame(f *Frame, threshold uint8, ratio float64) bool {
+	pixels := len(f.Data) / 4
+	if pixels == 0 {
+		return false
+	}
+
+	//the number of bright pixels which is allowed
+	allowed := int(float64(pixels) * (1 - ratio))
+
+	var bright int
+	for i := 0; i+4 <= len(f.Data); i += 4 {
+		if luma(f.Data[i:]) > threshold {
+			if bright++; bright > allowed {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// scanFrames calls fn for every frame of the reader with the time and duration of the frame
+func scanFrames(reader FrameReader, fn func(f *Frame, t, duration float32)) error {
+	duration := 1 / reader.Info().FrameRate
+
+	for {
+		f, err := reader.ReadFrame()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fn(f, f.Time, duration)
+	}
+}
+
+// DetectBlackFrames returns the ranges of at least minDuration seconds where at least ratio (0 - 1) of the pixels of each
+// frame have a luma below or equal to the threshold (0 - 255). The ranges are relative to the start of the reader.
+func DetectBlackFrames(reader FrameReader, threshold uint8, ratio float64, minDuration float32) ([]Range, error) {
+	c := &intervalCollector{minDuration: minDuration}
+
+	err := scanFrames(reader, func(f *Frame, t, duration float32) {
+		c.add(t, duration, isBlackFrame(f, threshold, ratio))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.close()
+	return c.ranges, nil
+}
+
+// frameDifference returns the mean absolute difference of the color channels of two frames (0 - 255)
+func frameDifference(a, b []byte) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return math.Inf(1)
+	}
+
+	var sum int
+	for i := 0; i < len(a); i++ {
+		if i%4 == 3 {
+			continue
+		}
+		d := int(a[i]) - int(b[i])
+		if d < 0 {
+			d = -d
+		}
+		sum += d
+	}
+
+	return float64(sum) / float64(len(a)/4*3)
+}
+
+// DetectFrozenFrames returns the ranges of at least minDuration seconds where the frames don't change. Frames are equal when the
+// mean difference of the color channels is below or equal to the tolerance (0 - 255). A small tolerance allows for compression noise.
+// The range starts at the first frame which is repeated.
+func DetectFrozenFrames(reader FrameReader, tolerance float64, minDuration float32) ([]Range, error) {
+	c := &intervalCollector{minDuration: minDuration}
+
+	var (
+		prev     []byte
+		prevTime float32
+	)
+
+	err := scanFrames(reader, func(f *Frame, t, duration float32) {
+		frozen := prev != nil && frameDifference(prev, f.Data) <= tolerance
+
+		if frozen && !c.active {
+			//the frozen range includes the frame which is repeated
+			c.add(prevTime, t-prevTime, true)
+		}
+		c.add(t, duration, frozen)
+
+		//the data might be reused by the reader
+		prev = append(prev[:0], f.Data...)
+		prevTime = t
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.close()
+	return c.ranges, nil
+}
+
+// TrimConfig describes what AutoTrim considers empty
+type TrimConfig struct {
+	// SilenceThreshold in dBFS
+	SilenceThreshold float64
+
+	// BlackThreshold is the highest luma (0 - 255) of a black pixel
+	BlackThreshold uint8
+
+	// BlackRatio is the part of the pixels (0 - 1) which should be black
+	BlackRatio float64
+}
+
+// DefaultTrimConfig treats audio below -60 dBFS as silence and frames with 98% of the pixels below a luma of 32 as black
+var DefaultTrimConfig = TrimConfig{SilenceThreshold: -60, BlackThreshold: 32, BlackRatio: .98}
+
+// leadingTrailing returns the end of the range at the start and the start of the range at the end of a duration
+func leadingTrailing(ranges []Range, duration float32) (start, end float32) {
+	const epsilon = 1e-3
+
+	end = duration
+	for _, r := range ranges {
+		if r.Start <= epsilon {
+			start = r.Start + r.Duration
+		}
+		if r.Start+r.Duration >= duration-epsilon {
+			end = r.Start
+		}
+	}
+	return
+}
+
+// AutoTrim removes the leading and trailing silence and black of the video. With both audio and video only the part where
+// both are empty is removed so no sound or picture is lost. The readers are scanned with a Slice so they can still be read.
+func AutoTrim(v *Video, config TrimConfig) (*Video, error) {
+	var (
+		start float32
+		end   float32
+		first = true
+	)
+
+	//narrow the trim to what is empty in all streams
+	include := func(s, e float32) {
+		if first {
+			start, end = s, e
+			first = false
+			return
+		}
+		start = float32(math.Min(float64(start), float64(s)))
+		end = float32(math.Max(float64(end), float64(e)))
+	}
+
+	if v.SampleReader != nil {
+		duration := sampleReaderDuration(v.SampleReader)
+
+		scan := v.SampleReader.Slice(&Range{Start: 0, Duration: duration})
+		ranges, err := DetectSilence(scan, config.SilenceThreshold, 0)
+		scan.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		include(leadingTrailing(ranges, duration))
+	}
+
+	if v.FrameReader != nil {
+		duration := frameReaderDuration(v.FrameReader)
+
+		scan := v.FrameReader.Slice(&Range{Start: 0, Duration: duration})
+		ranges, err := DetectBlackFrames(scan, config.BlackThreshold, config.BlackRatio, 0)
+		scan.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		include(leadingTrailing(ranges, duration))
+	}
+
+	if first || end <= start {
+		//nothing to scan or everything is empty
+		return v.Slice(&Range{Start: 0, Duration: 0})
+	}
+
+	return v.Slice(&Range{Start: start, Duration: end - start})
+}
@@ -0,0 +1,105 @@
+package gomovie_test
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < .02
+}
+
+func TestDetectSilence(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 2, Duration: 1}
+	reader := gomovie.Concat(
+		gomovie.NewToneReader(gomovie.Square, 100, .5, info),
+		gomovie.NewNullSampleReader(info),
+		gomovie.NewToneReader(gomovie.Square, 100, .5, info),
+	).SampleReader
+
+	ranges, err := gomovie.DetectSilence(reader, -60, .5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ranges) != 1 || !near(ranges[0].Start, 1) || !near(ranges[0].Duration, 1) {
+		t.Fatalf("Expected silence from 1 to 2 but got %v", ranges)
+	}
+}
+
+func colorClip(c color.Color, duration float32) gomovie.FrameReader {
+	return gomovie.NewColorClip(c, &gomovie.FrameReaderInfo{Width: 8, Height: 8, FrameRate: 25, Duration: duration})
+}
+
+func TestDetectBlackFrames(t *testing.T) {
+	reader := gomovie.Concat(colorClip(color.White, 1), colorClip(color.Black, 1), colorClip(color.White, 1)).FrameReader
+
+	ranges, err := gomovie.DetectBlackFrames(reader, 32, .98, .5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ranges) != 1 || !near(ranges[0].Start, 1) || !near(ranges[0].Duration, 1) {
+		t.Fatalf("Expected black from 1 to 2 but got %v", ranges)
+	}
+}
+
+func TestDetectFrozenFrames(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 64, Height: 36, FrameRate: 25, Duration: 1}
+	reader := gomovie.Concat(gomovie.NewTestPatternClip(info), colorClip(color.White, 1), gomovie.NewTestPatternClip(info)).FrameReader
+
+	ranges, err := gomovie.DetectFrozenFrames(reader, 1, .5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ranges) != 1 || !near(ranges[0].Start, 1) || !near(ranges[0].Duration, 1) {
+		t.Fatalf("Expected a frozen range from 1 to 2 but got %v", ranges)
+	}
+}
+
+func TestAutoTrim(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1}
+	silence := func(d float32) gomovie.SampleReader {
+		i := *info
+		i.Duration = d
+		return gomovie.NewNullSampleReader(&i)
+	}
+
+	tone := *info
+	tone.Duration = 3
+
+	v := &gomovie.Video{
+		FrameReader:  gomovie.Concat(colorClip(color.Black, 1), colorClip(color.White, 2), colorClip(color.Black, 1)).FrameReader,
+		SampleReader: gomovie.Concat(silence(.5), gomovie.NewToneReader(gomovie.Sine, 440, .5, &tone), silence(.5)).SampleReader,
+	}
+
+	trimmed, err := gomovie.AutoTrim(v, gomovie.DefaultTrimConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := trimmed.SampleReader.Range(); !near(r.Start, .5) || !near(r.Duration, 3) {
+		t.Fatalf("Expected the range .5 - 3.5 but got %v", r)
+	}
+
+	if samples := readFloats(t, trimmed.SampleReader); math.Abs(float64(len(samples)-24000)) > 16 {
+		t.Fatalf("Expected 24000 samples but got %v", len(samples))
+	}
+}
+
+func TestAutoTrimTransformer(t *testing.T) {
+	frames := gomovie.FadeFrames(colorClip(color.White, 2), .5, .5, gomovie.FadeLinear, color.Black)
+
+	trimmed, err := gomovie.AutoTrim(&gomovie.Video{FrameReader: frames}, gomovie.DefaultTrimConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := trimmed.FrameReader.Range(); r == nil || r.Start == 0 {
+		t.Fatalf("Expected the dark start of the fade to be trimmed but got %v", r)
+	}
+}
@@ -0,0 +1,57 @@
+package gomovie
+
+import "image/color"
+
+// fillRGBA fills the rgba data with a single color
+func fillRGBA(dst []byte, c color.NRGBA) {
+	for i := 0; i < len(dst); i += 4 {
+		dst[i], dst[i+1], dst[i+2], dst[i+3] = c.R, c.G, c.B, c.A
+	}
+}
+
+// drawOverRGBA composites the non premultiplied src (sw x sh) over dst (dw x dh) at offset ox, oy.
+// Opacity (0-255) is applied to the whole src. Parts of src outside of dst are clipped.
+func drawOverRGBA(dst []byte, dw, dh int, src []byte, sw, sh, ox, oy int, opacity uint32) {
+	x0, y0 := intMax(ox, 0), intMax(oy, 0)
+	x1, y1 := intMin(ox+sw, dw), intMin(oy+sh, dh)
+
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			s := ((y-oy)*sw + (x - ox)) * 4
+			d := (y*dw + x) * 4
+			blendOver(dst[d:d+4], src[s:s+4], opacity)
+		}
+	}
+}
+
+// blendOver blends the non premultiplied pixel s over d. Opacity (0-255) is multiplied with the alpha of s.
+func blendOver(d, s []byte, opacity uint32) {
+	sa := uint32(s[3]) * opacity / 255
+	if sa == 0 {
+		return
+	}
+	if sa == 255 {
+		d[0], d[1], d[2], d[3] = s[0], s[1], s[2], 255
+		return
+	}
+
+	da := uint32(d[3])
+	oa := sa*255 + da*(255-sa) //out alpha * 255
+
+	for i := 0; i < 3; i++ {
+		d[i] = uint8((uint32(s[i])*sa*255 + uint32(d[i])*da*(255-sa)) / oa)
+	}
+	d[3] = uint8(oa / 255)
+}
+
+// luma returns the Rec. 601 luma of the rgba pixel
+func luma(p []byte) uint8 {
+	return uint8((299*uint32(p[0]) + 587*uint32(p[1]) + 114*uint32(p[2])) / 1000)
+}
+
+func intMin(i1, i2 int) int {
+	if i2 < i1 {
+		return i2
+	}
+	return i1
+}
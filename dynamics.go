@@ -0,0 +1,280 @@
+package gomovie
+
+import "math"
+
+// envelopeCoefficient returns the smoothing factor for a time constant in seconds
+func envelopeCoefficient(seconds float32, rate int) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return math.Exp(-1 / (float64(seconds) * float64(rate)))
+}
+
+// smooth moves the value towards the target with the coefficient
+func smooth(value, target, coefficient float64) float64 {
+	return coefficient*value + (1-coefficient)*target
+}
+
+// framePeak returns the highest absolute value of the channels. The channels are linked so the stereo image doesn't shift.
+func framePeak(frame []float32) (peak float64) {
+	for _, v := range frame {
+		peak = math.Max(peak, math.Abs(float64(v)))
+	}
+	return
+}
+
+// toDecibels converts a level to dB with a floor to prevent -Inf
+func toDecibels(level float64) float64 {
+	return GainToDecibels(math.Max(level, 1e-10))
+}
+
+// Compressor describes the settings of a compressor. Levels are in dB and can be automated.
+type Compressor struct {
+	// Threshold in dBFS above which the level is reduced
+	Threshold Keyframes
+
+	// Ratio of the input level above the threshold to the output level. 4 means 4 dB above the threshold becomes 1 dB.
+	Ratio Keyframes
+
+	// Knee is the width in dB of the soft knee around the threshold. 0 is a hard knee.
+	Knee Keyframes
+
+	// MakeupGain in dB is applied after the compression
+	MakeupGain Keyframes
+
+	// Attack and Release in seconds
+	Attack, Release float32
+}
+
+// NewCompressor creates a Compressor with a 6 dB knee, 10 ms attack and 100 ms release
+func NewCompressor(threshold, ratio float64) Compressor {
+	return Compressor{
+		Threshold:  Constant(threshold),
+		Ratio:      Constant(ratio),
+		Knee:       Constant(6),
+		MakeupGain: Constant(0),
+		Attack:     .01,
+		Release:    .1,
+	}
+}
+
+// gainComputer returns the gain change in dB (0 or less) for the level in dB
+func gainComputer(level, threshold, ratio, knee float64) float64 {
+	if ratio < 1 {
+		ratio = 1
+	}
+
+	over := level - threshold
+
+	switch {
+	case 2*over <= -knee:
+		return 0
+	case knee > 0 && 2*math.Abs(over) < knee:
+		x := over + knee/2
+		return (1/ratio - 1) * x * x / (2 * knee)
+	}
+	return (1/ratio - 1) * over
+}
+
+// NewCompressorTransform creates a SampleTransform which reduces the dynamic range with the settings of the compressor
+func NewCompressorTransform(c Compressor) SampleTransform {
+	return SampleTransform{
+		Init: func(info *SampleReaderInfo) func(s *SampleBlock) {
+			rate := info.SampleRate
+			attack := envelopeCoefficient(c.Attack, rate)
+			release := envelopeCoefficient(c.Release, rate)
+
+			var reduction float64 //smoothed gain change in dB
+
+			return func(s *SampleBlock) {
+				processFrames(s, rate, info.Channels, func(frame []float32, t float32) {
+					target := gainComputer(toDecibels(framePeak(frame)), c.Threshold.At(t), c.Ratio.At(t), c.Knee.At(t))
+
+					if target < reduction {
+						reduction = smooth(reduction, target, attack)
+					} else {
+						reduction = smooth(reduction, target, release)
+					}
+
+					g := float32(DecibelsToGain(reduction + c.MakeupGain.At(t)))
+					for ch := range frame {
+						frame[ch] *= g
+					}
+				})
+			}
+		},
+	}
+}
+
+// NewLimiterTransform creates a SampleTransform which keeps the peaks below the ceiling (dBFS). The gain is lowered instantly
+// so the output never exceeds the ceiling and recovers in release seconds.
+func NewLimiterTransform(ceiling Keyframes, release float32) SampleTransform {
+	return SampleTransform{
+		Init: func(info *SampleReaderInfo) func(s *SampleBlock) {
+			rate := info.SampleRate
+			rel := envelopeCoefficient(release, rate)
+
+			gain := 1.
+
+			return func(s *SampleBlock) {
+				processFrames(s, rate, info.Channels, func(frame []float32, t float32) {
+					target := 1.
+					if peak := framePeak(frame); peak > 0 {
+						target = math.Min(1, DecibelsToGain(ceiling.At(t))/peak)
+					}
+
+					if target < gain {
+						gain = target
+					} else {
+						gain = smooth(gain, target, rel)
+					}
+
+					for ch := range frame {
+						frame[ch] *= float32(gain)
+					}
+				})
+			}
+		},
+	}
+}
+
+// NoiseGate describes the settings of a noise gate. Levels are in dB and can be automated.
+type NoiseGate struct {
+	// Threshold in dBFS below which the gate closes
+	Threshold Keyframes
+
+	// Range is the attenuation in dB when the gate is closed. For example -80.
+	Range Keyframes
+
+	// Attack is the time to open, Hold the time the gate stays open after the level drops and Release the time to close. In seconds.
+	Attack, Hold, Release float32
+}
+
+// NewNoiseGate creates a NoiseGate which closes completely with a 1 ms attack, 50 ms hold and 100 ms release
+func NewNoiseGate(threshold float64) NoiseGate {
+	return NoiseGate{
+		Threshold: Constant(threshold),
+		Range:     Constant(-80),
+		Attack:    .001,
+		Hold:      .05,
+		Release:   .1,
+	}
+}
+
+// NewNoiseGateTransform creates a SampleTransform which attenuates the audio when the level is below the threshold of the gate
+func NewNoiseGateTransform(g NoiseGate) SampleTransform {
+	return SampleTransform{
+		Init: func(info *SampleReaderInfo) func(s *SampleBlock) {
+			rate := info.SampleRate
+			attack := envelopeCoefficient(g.Attack, rate)
+			release := envelopeCoefficient(g.Release, rate)
+			hold := int(float64(g.Hold) * float64(rate))
+
+			var (
+				gain    float64
+				holding int
+			)
+
+			return func(s *SampleBlock) {
+				processFrames(s, rate, info.Channels, func(frame []float32, t float32) {
+					if toDecibels(framePeak(frame)) >= g.Threshold.At(t) {
+						holding = hold + 1
+					}
+
+					target := DecibelsToGain(g.Range.At(t))
+					if holding > 0 {
+						holding--
+						target = 1
+					}
+
+					if target > gain {
+						gain = smooth(gain, target, attack)
+					} else {
+						gain = smooth(gain, target, release)
+					}
+
+					for ch := range frame {
+						frame[ch] *= float32(gain)
+					}
+				})
+			}
+		},
+	}
+}
+
+// DeEsser describes the settings of a split band de-esser. Only the frequencies above Frequency are reduced.
+type DeEsser struct {
+	// Frequency in Hz where the sibilance band starts. Usually between 4 and 8 kHz.
+	Frequency Keyframes
+
+	// Threshold in dBFS of the sibilance band
+	Threshold Keyframes
+
+	// Ratio of the reduction above the threshold
+	Ratio Keyframes
+
+	// Attack and Release in seconds
+	Attack, Release float32
+}
+
+// NewDeEsser creates a DeEsser for the band above 6 kHz with a ratio of 4, 1 ms attack and 50 ms release
+func NewDeEsser(threshold float64) DeEsser {
+	return DeEsser{
+		Frequency: Constant(6000),
+		Threshold: Constant(threshold),
+		Ratio:     Constant(4),
+		Attack:    .001,
+		Release:   .05,
+	}
+}
+
+// NewDeEsserTransform creates a SampleTransform which compresses the sibilance band with the settings of the de-esser
+func NewDeEsserTransform(d DeEsser) SampleTransform {
+	band := EQBand{Filter: HighPass, Frequency: d.Frequency}
+
+	return SampleTransform{
+		Init: func(info *SampleReaderInfo) func(s *SampleBlock) {
+			rate := info.SampleRate
+			attack := envelopeCoefficient(d.Attack, rate)
+			release := envelopeCoefficient(d.Release, rate)
+
+			filters := make([]biquad, info.Channels)
+			for ch := range filters {
+				filters[ch] = band.coefficients(0, rate)
+			}
+
+			high := make([]float32, info.Channels)
+
+			var reduction float64
+
+			return func(s *SampleBlock) {
+				processFrames(s, rate, info.Channels, func(frame []float32, t float32) {
+					if band.animated() {
+						c := band.coefficients(t, rate)
+						for ch := range filters {
+							filters[ch].setCoefficients(c.b0, c.b1, c.b2, c.a1, c.a2)
+						}
+					}
+
+					for ch, v := range frame {
+						high[ch] = float32(filters[ch].process(float64(v)))
+					}
+
+					target := gainComputer(toDecibels(framePeak(high)), d.Threshold.At(t), d.Ratio.At(t), 0)
+
+					if target < reduction {
+						reduction = smooth(reduction, target, attack)
+					} else {
+						reduction = smooth(reduction, target, release)
+					}
+
+					//only reduce the high band. Without reduction the output is the same as the input.
+					g := float32(DecibelsToGain(reduction))
+					for ch := range frame {
+						frame[ch] -= high[ch] * (1 - g)
+					}
+				})
+			}
+		},
+	}
+}
@@ -0,0 +1,106 @@
+package gomovie_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func peakAfter(samples []float32, from int) (peak float64) {
+	for _, v := range samples[from:] {
+		peak = math.Max(peak, math.Abs(float64(v)))
+	}
+	return
+}
+
+func TestCompressorTransform(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1}
+	reader := gomovie.NewToneReader(gomovie.Square, 100, gomovie.DecibelsToGain(-6), info)
+
+	c := gomovie.NewCompressor(-20, 4)
+	c.Knee = gomovie.Constant(0)
+
+	samples := readFloats(t, gomovie.NewSampleTransformer(reader).AddTransform(gomovie.NewCompressorTransform(c)))
+
+	//14 dB above the threshold becomes 3.5 dB
+	if p := gomovie.GainToDecibels(peakAfter(samples, 4000)); math.Abs(p+16.5) > .1 {
+		t.Fatalf("Expected -16.5 dBFS but got %v", p)
+	}
+}
+
+func TestLimiterTransform(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 2, Duration: .5}
+	reader := gomovie.NewToneReader(gomovie.Sine, 440, 1, info)
+
+	samples := readFloats(t, gomovie.NewSampleTransformer(reader).AddTransform(gomovie.NewLimiterTransform(gomovie.Constant(-6), .05)))
+
+	if p := peakAfter(samples, 0); p > gomovie.DecibelsToGain(-6)+1e-4 {
+		t.Fatalf("Expected the peak to stay below the ceiling but got %v", p)
+	}
+}
+
+func TestNoiseGateTransform(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1}
+	reader := gomovie.NewToneReader(gomovie.Sine, 440, gomovie.DecibelsToGain(-50), info)
+
+	samples := readFloats(t, gomovie.NewSampleTransformer(reader).AddTransform(gomovie.NewNoiseGateTransform(gomovie.NewNoiseGate(-40))))
+
+	if p := gomovie.GainToDecibels(peakAfter(samples, 0)); p > -100 {
+		t.Fatalf("Expected the gate to stay closed but got %v dBFS", p)
+	}
+}
+
+func TestEQTransform(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 48000, Channels: 1, Duration: .5}
+
+	lowPass := gomovie.NewEQTransform(gomovie.NewEQBand(gomovie.LowPass, 1000, 0, .7071))
+	high := readFloats(t, gomovie.NewSampleTransformer(gomovie.NewToneReader(gomovie.Sine, 8000, .5, info)).AddTransform(lowPass))
+
+	if p := peakAfter(high, 12000); p > .5*gomovie.DecibelsToGain(-30) {
+		t.Fatalf("Expected 8 kHz to be removed but got %v", p)
+	}
+
+	peaking := gomovie.NewEQTransform(gomovie.NewEQBand(gomovie.Peaking, 1000, 6, 1))
+	boosted := readFloats(t, gomovie.NewSampleTransformer(gomovie.NewToneReader(gomovie.Sine, 1000, .25, info)).AddTransform(peaking))
+
+	if p := gomovie.GainToDecibels(peakAfter(boosted, 12000) / .25); math.Abs(p-6) > .1 {
+		t.Fatalf("Expected a 6 dB boost but got %v", p)
+	}
+}
+
+func TestEQStateAcrossBlocks(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 2, Duration: .25}
+	eq := gomovie.NewEQTransform(gomovie.NewEQBand(gomovie.HighShelf, 2000, -12, .7071))
+
+	read := func(blockSize int) []float32 {
+		reader := gomovie.NewNoiseReader(gomovie.WhiteNoise, .5, info)
+		reader.SampleFormat().Depth = 32
+		reader.SampleFormat().Float = true
+		reader.SampleFormat().BlockSize = blockSize
+		return readFloats(t, gomovie.NewSampleTransformer(reader).AddTransform(eq))
+	}
+
+	a, b := read(8), read(4096)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("Expected the same output for every block size. Differs at %v", i)
+		}
+	}
+}
+
+func TestSampleTransformerSlice(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1}
+	reader := gomovie.NewToneReader(gomovie.Square, 100, 1, info)
+
+	transformer := gomovie.NewSampleTransformer(reader).AddTransform(gomovie.NewLimiterTransform(gomovie.Constant(-6), .05))
+
+	samples := readFloats(t, transformer.Slice(&gomovie.Range{Start: .5, Duration: .25}))
+	if len(samples) != 2000 {
+		t.Fatalf("Expected 2000 samples but got %v", len(samples))
+	}
+
+	if p := peakAfter(samples, 0); p > .51 {
+		t.Fatalf("Expected the slice to keep the limiter but got %v", p)
+	}
+}
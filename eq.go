@@ -0,0 +1,145 @@
+package gomovie
+
+import "math"
+
+// EQFilter describes the shape of an EQBand
+type EQFilter int
+
+const (
+	// Peaking boosts or cuts the frequencies around the frequency
+	Peaking EQFilter = iota
+	// LowShelf boosts or cuts the frequencies below the frequency
+	LowShelf
+	// HighShelf boosts or cuts the frequencies above the frequency
+	HighShelf
+	// LowPass removes the frequencies above the frequency. The gain is ignored.
+	LowPass
+	// HighPass removes the frequencies below the frequency. The gain is ignored.
+	HighPass
+)
+
+// EQBand describes a single filter of a parametric EQ. All the parameters can be automated.
+type EQBand struct {
+	Filter EQFilter
+
+	// Frequency in Hz
+	Frequency Keyframes
+
+	// Gain in dB. Only used by Peaking and the shelves.
+	Gain Keyframes
+
+	// Q describes the width of the band. The default is 0.7071 (no resonance for the pass filters).
+	Q Keyframes
+}
+
+// NewEQBand creates an EQBand with constant parameters
+func NewEQBand(filter EQFilter, frequency, gain, q float64) EQBand {
+	return EQBand{Filter: filter, Frequency: Constant(frequency), Gain: Constant(gain), Q: Constant(q)}
+}
+
+// animated is true when one of the parameters changes over time
+func (b EQBand) animated() bool {
+	return len(b.Frequency) > 1 || len(b.Gain) > 1 || len(b.Q) > 1
+}
+
+// coefficients returns the filter for the parameters at time t. Uses the formulas of the RBJ audio EQ cookbook.
+func (b EQBand) coefficients(t float32, rate int) (f biquad) {
+	q := b.Q.At(t)
+	if q <= 0 {
+		q = math.Sqrt2 / 2
+	}
+
+	//keep the frequency below nyquist
+	freq := math.Min(math.Max(b.Frequency.At(t), 1), float64(rate)*.49)
+
+	a := math.Pow(10, b.Gain.At(t)/40)
+	w0 := 2 * math.Pi * freq / float64(rate)
+	sin, cos := math.Sincos(w0)
+	alpha := sin / (2 * q)
+	sqrtA := 2 * math.Sqrt(a) * alpha
+
+	var b0, b1, b2, a0, a1, a2 float64
+
+	switch b.Filter {
+	case LowShelf:
+		b0 = a * ((a + 1) - (a-1)*cos + sqrtA)
+		b1 = 2 * a * ((a - 1) - (a+1)*cos)
+		b2 = a * ((a + 1) - (a-1)*cos - sqrtA)
+		a0 = (a + 1) + (a-1)*cos + sqrtA
+		a1 = -2 * ((a - 1) + (a+1)*cos)
+		a2 = (a + 1) + (a-1)*cos - sqrtA
+	case HighShelf:
+		b0 = a * ((a + 1) + (a-1)*cos + sqrtA)
+		b1 = -2 * a * ((a - 1) + (a+1)*cos)
+		b2 = a * ((a + 1) + (a-1)*cos - sqrtA)
+		a0 = (a + 1) - (a-1)*cos + sqrtA
+		a1 = 2 * ((a - 1) - (a+1)*cos)
+		a2 = (a + 1) - (a-1)*cos - sqrtA
+	case LowPass:
+		b0, b1, b2 = (1-cos)/2, 1-cos, (1-cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case HighPass:
+		b0, b1, b2 = (1+cos)/2, -(1 + cos), (1+cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	default:
+		b0, b1, b2 = 1+alpha*a, -2*cos, 1-alpha*a
+		a0, a1, a2 = 1+alpha/a, -2*cos, 1-alpha/a
+	}
+
+	f.setCoefficients(b0/a0, b1/a0, b2/a0, a1/a0, a2/a0)
+	return
+}
+
+// setCoefficients changes the filter without resetting the state
+func (f *biquad) setCoefficients(b0, b1, b2, a1, a2 float64) {
+	f.b0, f.b1, f.b2, f.a1, f.a2 = b0, b1, b2, a1, a2
+}
+
+// processFrames calls fn for every frame of interleaved samples of the block with the time of the frame in seconds
+func processFrames(s *SampleBlock, rate, channels int, fn func(frame []float32, t float32)) {
+	f := s.Floats()
+
+	for i := 0; i+channels <= len(f); i += channels {
+		fn(f[i:i+channels], s.Time+float32(i/channels)/float32(rate))
+	}
+
+	s.SetFloats(f)
+}
+
+// NewEQTransform creates a SampleTransform which applies the bands in order. The filter state is kept between the blocks.
+func NewEQTransform(bands ...EQBand) SampleTransform {
+	return SampleTransform{
+		Init: func(info *SampleReaderInfo) func(s *SampleBlock) {
+			rate, channels := info.SampleRate, info.Channels
+
+			filters := make([][]biquad, len(bands))
+			for i, b := range bands {
+				c := b.coefficients(0, rate)
+
+				filters[i] = make([]biquad, channels)
+				for ch := range filters[i] {
+					filters[i][ch] = c
+				}
+			}
+
+			return func(s *SampleBlock) {
+				processFrames(s, rate, channels, func(frame []float32, t float32) {
+					for i, b := range bands {
+						fs := filters[i]
+
+						if b.animated() {
+							c := b.coefficients(t, rate)
+							for ch := range fs {
+								fs[ch].setCoefficients(c.b0, c.b1, c.b2, c.a1, c.a2)
+							}
+						}
+
+						for ch, v := range frame {
+							frame[ch] = float32(fs[ch].process(float64(v)))
+						}
+					}
+				})
+			}
+		},
+	}
+}
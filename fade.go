@@ -0,0 +1,212 @@
+package gomovie
+
+import (
+	"image/color"
+	"math"
+)
+
+// FadeCurve describes the shape of a fade
+type FadeCurve int
+
+const (
+	// FadeLinear changes the gain at a constant speed
+	FadeLinear FadeCurve = iota
+	// FadeLogarithmic changes the level in dB at a constant speed (over 60 dB) which sounds even to the ear
+	FadeLogarithmic
+	// FadeSCurve starts and ends slowly
+	FadeSCurve
+)
+
+// gain returns the gain for the progress p of the fade (0 is silent, 1 is full level)
+func (c FadeCurve) gain(p float64) float64 {
+	switch {
+	case p <= 0:
+		return 0
+	case p >= 1:
+		return 1
+	}
+
+	switch c {
+	case FadeLogarithmic:
+		return DecibelsToGain((p - 1) * 60)
+	case FadeSCurve:
+		return (1 - math.Cos(math.Pi*p)) / 2
+	}
+	return p
+}
+
+// fadeGain returns the gain at time t for a fade in of in seconds at the start and a fade out of out seconds before end
+func fadeGain(t, in, out, end float32, curve FadeCurve) float64 {
+	p := 1.
+	if in > 0 && t < in {
+		p = math.Min(p, float64(t/in))
+	}
+	if out > 0 && t > end-out {
+		p = math.Min(p, float64((end-t)/out))
+	}
+	return curve.gain(p)
+}
+
+// NewFadeTransform creates a SampleTransform which fades in during the first in seconds and fades out during the out seconds before end.
+// The time of the SampleBlock is used so the fades stay in place after a Slice of the SampleTransformer.
+func NewFadeTransform(in, out, end float32, curve FadeCurve) SampleTransform {
+	return SampleTransform{
+		Init: func(info *SampleReaderInfo) func(s *SampleBlock) {
+			return func(s *SampleBlock) {
+				processFrames(s, info.SampleRate, info.Channels, func(frame []float32, t float32) {
+					g := float32(fadeGain(t, in, out, end, curve))
+					for ch := range frame {
+						frame[ch] *= g
+					}
+				})
+			}
+		},
+	}
+}
+
+// NewFrameFadeTransform creates a FrameTransform which fades from the color during the first in seconds and to the color during
+// the out seconds before end. Use a transparent color to fade the alpha.
+func NewFrameFadeTransform(in, out, end float32, curve FadeCurve, c color.Color) FrameTransform {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	target := [4]float64{float64(n.R), float64(n.G), float64(n.B), float64(n.A)}
+
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			g := fadeGain(f.Time, in, out, end, curve)
+			if g >= 1 {
+				return
+			}
+
+			for i, v := range f.Data {
+				f.Data[i] = clampUint8(float32(float64(v)*g + target[i%4]*(1-g)))
+			}
+		},
+	}
+}
+
+// FadeSamples fades the reader in and out with the curve
+func FadeSamples(reader SampleReader, in, out float32, curve FadeCurve) *SampleTransformer {
+	return NewSampleTransformer(reader).AddTransform(NewFadeTransform(in, out, sampleReaderDuration(reader), curve))
+}
+
+// FadeFrames fades the reader in from and out to the color with the curve
+func FadeFrames(reader FrameReader, in, out float32, curve FadeCurve, c color.Color) *FrameTransformer {
+	return NewFrameTransformer(reader).AddTransform(NewFrameFadeTransform(in, out, frameReaderDuration(reader), curve, c))
+}
+
+// FadeVideo fades the frames of the video in from and out to black and the samples in and out of silence
+func FadeVideo(v *Video, in, out float32, curve FadeCurve) *Video {
+	f := &Video{Subtitles: v.Subtitles}
+
+	if v.FrameReader != nil {
+		f.FrameReader = FadeFrames(v.FrameReader, in, out, curve, color.Black)
+	}
+
+	if v.SampleReader != nil {
+		f.SampleReader = FadeSamples(v.SampleReader, in, out, curve)
+	}
+
+	return f
+}
+
+// NewGainTransform creates a SampleTransform with a gain envelope in dB. Unlike NewVolumeTransform the keyframes
+// are interpolated in dB which sounds more natural for long ramps.
+func NewGainTransform(gain Keyframes) SampleTransform {
+	return SampleTransform{
+		Init: func(info *SampleReaderInfo) func(s *SampleBlock) {
+			return func(s *SampleBlock) {
+				processFrames(s, info.SampleRate, info.Channels, func(frame []float32, t float32) {
+					g := float32(DecibelsToGain(gain.At(t)))
+					for ch := range frame {
+						frame[ch] *= g
+					}
+				})
+			}
+		},
+	}
+}
+
+// Ducking describes how a track is lowered when the sidechain (usually a voice) is active
+type Ducking struct {
+	// Threshold in dBFS of the sidechain above which the track is lowered
+	Threshold float64
+
+	// Amount in dB the track is lowered. For example -12.
+	Amount float64
+
+	// Attack is the time to lower the track, Hold the time it stays lowered after the sidechain drops below the threshold
+	// and Release the time to return to the full level. In seconds.
+	Attack, Hold, Release float32
+}
+
+// NewDucking creates Ducking with a 50 ms attack, 300 ms hold and 500 ms release
+func NewDucking(threshold, amount float64) Ducking {
+	return Ducking{Threshold: threshold, Amount: amount, Attack: .05, Hold: .3, Release: .5}
+}
+
+// NewDuckingTransform creates a SampleTransform which lowers the audio when the sidechain exceeds the threshold.
+// The sidechain is converted to the sample rate and channels of the stream and sliced from the time of the first block
+// so it stays in sync after a Slice of the SampleTransformer.
+func NewDuckingTransform(sidechain SampleReader, d Ducking) SampleTransform {
+	return SampleTransform{
+		Init: func(info *SampleReaderInfo) func(s *SampleBlock) {
+			rate := info.SampleRate
+			attack := envelopeCoefficient(d.Attack, rate)
+			release := envelopeCoefficient(d.Release, rate)
+			hold := int(float64(d.Hold) * float64(rate))
+
+			var (
+				side      *floatFrameReader
+				reduction float64
+				holding   int
+			)
+
+			return func(s *SampleBlock) {
+				if side == nil {
+					r := &Range{Start: s.Time, Duration: sampleReaderDuration(sidechain)}
+					side = newFloatFrameReader(convertSampleReader(sidechain.Slice(r), rate, info.Channels))
+				}
+
+				frames := s.Len() / info.Channels
+
+				//silence when the sidechain ended (or failed)
+				voice, _ := side.read(frames)
+
+				i := 0
+				processFrames(s, rate, info.Channels, func(frame []float32, t float32) {
+					var level float64
+					if o := i * info.Channels; o < len(voice) {
+						level = framePeak(voice[o : o+info.Channels])
+					}
+					i++
+
+					if toDecibels(level) >= d.Threshold {
+						holding = hold + 1
+					}
+
+					target := 0.
+					if holding > 0 {
+						holding--
+						target = d.Amount
+					}
+
+					if target < reduction {
+						reduction = smooth(reduction, target, attack)
+					} else {
+						reduction = smooth(reduction, target, release)
+					}
+
+					g := float32(DecibelsToGain(reduction))
+					for ch := range frame {
+						frame[ch] *= g
+					}
+				})
+			}
+		},
+	}
+}
+
+// Duck lowers the music whenever the voice exceeds the threshold of the ducking
+func Duck(music, voice SampleReader, d Ducking) *SampleTransformer {
+	return NewSampleTransformer(music).AddTransform(NewDuckingTransform(voice, d))
+}
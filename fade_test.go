@@ -0,0 +1,115 @@
+package gomovie_test
+
+import (
+	"image/color"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func squareReader(duration float32) gomovie.SampleReader {
+	return gomovie.NewToneReader(gomovie.Square, 100, 1, &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: duration})
+}
+
+func levelAt(samples []float32, t float32) float64 {
+	return math.Abs(float64(samples[int(t*8000)]))
+}
+
+func TestFadeSamples(t *testing.T) {
+	samples := readFloats(t, gomovie.FadeSamples(squareReader(4), 1, 1, gomovie.FadeLinear))
+
+	if l := levelAt(samples, 0); l != 0 {
+		t.Fatalf("Expected silence at the start but got %v", l)
+	}
+
+	if l := levelAt(samples, .5); math.Abs(l-.5) > .01 {
+		t.Fatalf("Expected half the level at .5 but got %v", l)
+	}
+
+	if l := levelAt(samples, 2); l < .99 {
+		t.Fatalf("Expected the full level at 2 but got %v", l)
+	}
+
+	if l := levelAt(samples, 3.5); math.Abs(l-.5) > .01 {
+		t.Fatalf("Expected half the level at 3.5 but got %v", l)
+	}
+}
+
+func TestFadeAfterSlice(t *testing.T) {
+	faded := gomovie.FadeSamples(squareReader(4), 1, 1, gomovie.FadeSCurve)
+
+	//the fade in is not part of the slice. The fade out stays at the end of the source.
+	samples := readFloats(t, faded.Slice(&gomovie.Range{Start: 1.5, Duration: 2.5}))
+
+	if l := levelAt(samples, 0); l < .99 {
+		t.Fatalf("Expected the full level at the start of the slice but got %v", l)
+	}
+
+	if l := levelAt(samples, 2); math.Abs(l-.5) > .01 {
+		t.Fatalf("Expected half the level at 2 but got %v", l)
+	}
+}
+
+func TestFadeAfterConcat(t *testing.T) {
+	joined := gomovie.Concat(squareReader(1), squareReader(1)).SampleReader
+
+	samples := readFloats(t, gomovie.FadeSamples(joined, 0, 1, gomovie.FadeLinear))
+	if len(samples) != 16000 {
+		t.Fatalf("Expected 16000 samples but got %v", len(samples))
+	}
+
+	if l := levelAt(samples, 1.5); math.Abs(l-.5) > .01 {
+		t.Fatalf("Expected half the level at 1.5 but got %v", l)
+	}
+}
+
+func TestConcatSampleSlice(t *testing.T) {
+	joined := gomovie.Concat(squareReader(1), squareReader(1)).SampleReader
+
+	samples := readFloats(t, joined.Slice(&gomovie.Range{Start: .5, Duration: 1}))
+	if len(samples) != 8000 {
+		t.Fatalf("Expected 8000 samples but got %v", len(samples))
+	}
+}
+
+func TestFadeFrames(t *testing.T) {
+	clip := gomovie.NewColorClip(color.White, &gomovie.FrameReaderInfo{Width: 4, Height: 4, FrameRate: 10, Duration: 2})
+	faded := gomovie.FadeFrames(clip, 1, 0, gomovie.FadeLinear, color.Black)
+
+	var values []byte
+	for {
+		f, err := faded.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, f.Data[0])
+	}
+
+	if values[0] != 0 || values[5] != 128 || values[15] != 255 {
+		t.Fatalf("Unexpected fade %v", values)
+	}
+}
+
+func TestDuck(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 2}
+	music := gomovie.NewToneReader(gomovie.Square, 100, .5, info)
+	voice := gomovie.NewBeepReader(2, 1, .5, info)
+
+	d := gomovie.NewDucking(-30, -12)
+	d.Release = .05
+
+	samples := readFloats(t, gomovie.Duck(music, voice, d))
+
+	if l := gomovie.GainToDecibels(levelAt(samples, .5) / .5); math.Abs(l+12) > .1 {
+		t.Fatalf("Expected the music to be lowered 12 dB but got %v", l)
+	}
+
+	if l := levelAt(samples, 1.9); math.Abs(l-.5) > .01 {
+		t.Fatalf("Expected the music to be back at full level but got %v", l)
+	}
+}
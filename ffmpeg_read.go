@@ -1,38 +1,98 @@
 package gomovie
 
 import (
-	"bytes"
-	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"os/exec"
 	"strconv"
 )
 
+var invalidRotationError = errors.New("Rotation should be a multiple of 90 degrees")
+
+// RotationMode describes how the rotation metadata of a video (mostly phone footage) is handled
+type RotationMode int
+
+const (
+	//AutoRotate lets ffmpeg rotate the frames according to the display matrix. This is the default.
+	AutoRotate RotationMode = iota
+	//KeepRawRotation ignores the display matrix. Frames are returned as they are stored.
+	KeepRawRotation
+	//ForceRotation ignores the display matrix and rotates the frames by OpenConfig.Rotation instead
+	ForceRotation
+)
+
+// OpenConfig contains the options for FfmpegOpenWithConfig
+type OpenConfig struct {
+	RotationMode RotationMode
+
+	//Clockwise rotation in degrees for ForceRotation. Should be a multiple of 90
+	Rotation int
+
+	//Pooled takes the frames and sample blocks from a FramePool and SampleBlockPool.
+	//They should be released with Release once they are no longer used.
+	Pooled bool
+}
+
+// FfmpegOpen opens the video at path. Rotated video will be rotated automatically.
 func FfmpegOpen(path string) (vid *Video, err error) {
+	return FfmpegOpenWithConfig(path, OpenConfig{})
+}
+
+// FfmpegOpenWithConfig opens the video at path using the given config
+func FfmpegOpenWithConfig(path string, config OpenConfig) (vid *Video, err error) {
 	frameInfo, audioInfo, err := ExtractInfo(path)
 	if err != nil {
 		return
 	}
 
+	if frameInfo != nil && config.RotationMode != AutoRotate {
+		if frameInfo, err = rotatedFrameInfo(frameInfo, config); err != nil {
+			return
+		}
+	}
+
 	var (
 		v *FfmpegRGBAStream
 		a *FfmpegPCMStream
 	)
 
 	if frameInfo != nil {
-		v = &FfmpegRGBAStream{Path: path, i: frameInfo}
+		v = &FfmpegRGBAStream{Path: path, i: frameInfo, c: config}
 	}
 
 	if audioInfo != nil {
-		a = &FfmpegPCMStream{Path: path, o: NewSampleFormat(), i: audioInfo}
+		a = &FfmpegPCMStream{Path: path, o: NewSampleFormat(), i: audioInfo, pooled: config.Pooled}
 	}
 
-	vid = &Video{v, a}
+	vid = &Video{FrameReader: v, SampleReader: a}
 
 	return
 }
 
+// rotatedFrameInfo returns a copy of the (auto rotated) info with the size of the frames as they will be returned for the config
+func rotatedFrameInfo(info *FrameReaderInfo, config OpenConfig) (*FrameReaderInfo, error) {
+	i := *info
+
+	//undo the swap done by ExtractInfo
+	if i.DisplayRotation()%180 == 90 {
+		i.Width, i.Height = i.Height, i.Width
+	}
+
+	if config.RotationMode == ForceRotation {
+		rotation, ok := normalizeRotation(config.Rotation)
+		if !ok {
+			return nil, invalidRotationError
+		}
+
+		if rotation%180 == 90 {
+			i.Width, i.Height = i.Height, i.Width
+		}
+	}
+
+	return &i, nil
+}
+
 type FfmpegPCMStream struct {
 	Path       string
 	Start      float64
@@ -44,16 +104,34 @@ type FfmpegPCMStream struct {
 	o *SampleFormat
 	r *Range
 
-	sampleDepth int
-	cmd         *exec.Cmd
-	stdout      io.ReadCloser
-	stderr      io.ReadCloser
-	offset      int
-	opened      bool
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	stderr io.ReadCloser
+	offset int
+	opened bool
+
+	buf    []byte
+	pooled bool
+	pool   *SampleBlockPool
 }
 
-func (src *FfmpegPCMStream) Range() *Range           { return src.r }
-func (src *FfmpegPCMStream) Info() *SampleReaderInfo { return src.i }
+func (src *FfmpegPCMStream) Range() *Range { return src.r }
+
+// Info returns the info of the audio stream. SampleRate and Channels are replaced when they are overridden.
+func (src *FfmpegPCMStream) Info() *SampleReaderInfo {
+	if src.SampleRate == 0 && src.Channels == 0 {
+		return src.i
+	}
+
+	i := *src.i
+	if src.SampleRate != 0 {
+		i.SampleRate = src.SampleRate
+	}
+	if src.Channels != 0 {
+		i.Channels = src.Channels
+	}
+	return &i
+}
 
 func (src *FfmpegPCMStream) Slice(r *Range) SampleReader {
 	var dur float32
@@ -69,10 +147,23 @@ func (src *FfmpegPCMStream) Slice(r *Range) SampleReader {
 		r.parent = src.r.parent
 	}
 
-	return &FfmpegPCMStream{Path: src.Path, i: src.i, r: r}
+	o := *src.o
+
+	return &FfmpegPCMStream{
+		Path:       src.Path,
+		Channels:   src.Channels,
+		SampleRate: src.SampleRate,
+		i:          src.i,
+		o:          &o,
+		r:          r,
+		pooled:     src.pooled,
+	}
 }
 
 func (src *FfmpegPCMStream) Close() (err error) {
+	if src.cmd == nil { //never opened
+		return
+	}
 	err = src.cmd.Process.Kill()
 	return
 }
@@ -92,22 +183,27 @@ func (src *FfmpegPCMStream) open() (err error) {
 		"-vn",
 	}
 
-	if src.Start > 0 {
+	start, duration := src.Start, src.Duration
+	if src.r != nil {
+		start, duration = float64(src.r.Start), float64(src.r.Duration)
+	}
+
+	if start > 0 {
 		args = append(args,
 			"-ss",
-			strconv.FormatFloat(src.Start, 'f', -1, 32),
+			strconv.FormatFloat(start, 'f', -1, 32),
 		)
 	}
 
-	if src.Duration > 0 {
+	if duration > 0 {
 		args = append(args,
 			"-t",
-			strconv.FormatFloat(src.Duration, 'f', -1, 32),
+			strconv.FormatFloat(duration, 'f', -1, 32),
 		)
 	}
 
 	args = append(args,
-		"-f", fmt.Sprintf("s%vle", src.sampleDepth),
+		"-f", src.o.FfmpegFormat(),
 	)
 
 	if src.Channels != 0 {
@@ -159,43 +255,49 @@ func (src *FfmpegPCMStream) ReadSampleBlock() (*SampleBlock, error) {
 		}
 	}
 
-	bytesPerSample := src.sampleDepth / 8
+	info := src.Info()
+	bytesPerSample := src.o.BytesPerSample()
+	frameSize := bytesPerSample * info.Channels
 
 	//TODO there might not be enough data to fill the whole sample block
 	//so we need to update the duration to reflect the cropped data
 
-	b := make([]byte, src.o.BlockSize) //todo reuse this buffer
-	br, _ := io.ReadFull(src.stdout, b)
-	b = b[:br]
+	//only whole frames so 24 bit samples and channels are never split between blocks
+	size := intMax(src.o.BlockSize/frameSize, 1) * frameSize
+	if len(src.buf) != size {
+		src.buf = make([]byte, size)
+	}
 
-	var sampleData interface{}
+	br, _ := io.ReadFull(src.stdout, src.buf)
+	br -= br % frameSize
+	b := src.buf[:br]
 
-	switch src.sampleDepth {
-	case 32:
-		sampleData = make([]SampleInt32, br/bytesPerSample)
-	default:
-		sampleData = make([]SampleInt16, br/bytesPerSample)
+	if br == 0 {
+		return nil, io.EOF
 	}
 
-	if err := binary.Read(bytes.NewBuffer(b), binary.LittleEndian, sampleData); err != nil {
-
-		//process was already closed but we are still trying to read from it
-		if src.cmd.ProcessState != nil {
-			return nil, io.EOF
+	var sb *SampleBlock
+	if src.pooled {
+		//the format can be changed between reads (like the BlockSize or Layout) while the pool keeps its own copy
+		if src.pool == nil || *src.pool.format != *src.o {
+			format := *src.o
+			src.pool = NewSampleBlockPool(&format, info.Channels)
 		}
-
-		return nil, err
+		sb = src.pool.Get(br / bytesPerSample)
+		sb.decodeBytes(b)
+	} else {
+		sb = parseSampleBytes(b, src.o, info.Channels)
 	}
 
-	a := float32(bytesPerSample*src.i.SampleRate) / float32(src.i.Channels)
+	//bytes per second. 44100 samples per second. 2 bytes (16 bit) per sample for each channel
+	a := float32(bytesPerSample * info.SampleRate * info.Channels)
 
-	//44100 samples per second. 2 bytes (16 bit) per sample
-	time := float32(src.offset) / a
-	duration := float32(br) / a
+	sb.Time = float32(src.offset) / a
+	sb.Duration = float32(br) / a
 
 	src.offset += br
 
-	return &SampleBlock{src.o, sampleData, time, duration}, nil
+	return sb, nil
 }
 
 func (src *FfmpegPCMStream) SampleFormat() *SampleFormat {
@@ -216,12 +318,15 @@ type FfmpegRGBAStream struct {
 
 	i *FrameReaderInfo
 	r *Range
+	c OpenConfig
 
 	cmd    *exec.Cmd
 	stdout io.ReadCloser
 	stderr io.ReadCloser
 	offset int64
 	opened bool
+
+	pool *FramePool
 }
 
 func (g *FfmpegRGBAStream) Range() *Range {
@@ -242,10 +347,13 @@ func (g *FfmpegRGBAStream) Slice(r *Range) FrameReader {
 		r.parent = g.r.parent
 	}
 
-	return &FfmpegRGBAStream{Path: g.Path, i: g.i, r: r}
+	return &FfmpegRGBAStream{Path: g.Path, i: g.i, r: r, c: g.c}
 }
 
 func (g *FfmpegRGBAStream) Close() (err error) {
+	if g.cmd == nil { //never opened
+		return
+	}
 	err = g.cmd.Process.Kill()
 	return
 }
@@ -259,22 +367,43 @@ func (g *FfmpegRGBAStream) open() (err error) {
 
 	args := []string{
 		"-loglevel", "error",
+	}
+
+	if g.c.RotationMode != AutoRotate {
+		args = append(args, "-noautorotate")
+	}
 
+	//seek before the input so ffmpeg jumps to the nearest keyframe instead of decoding everything before the start.
+	//Frames are still decoded from that keyframe so the seek is accurate.
+	if g.r != nil && g.r.Start > 0 {
+		args = append(args,
+			"-ss",
+			strconv.FormatFloat(float64(g.r.Start), 'f', -1, 32),
+		)
+	}
+
+	args = append(args,
 		"-i", g.Path,
 
 		"-f", "image2pipe",
 		"-pix_fmt", "rgba",
 		"-vcodec", "rawvideo",
-	}
+	)
 
-	if g.r != nil {
-		if g.r.Start > 0 {
-			args = append(args,
-				"-ss",
-				strconv.FormatFloat(float64(g.r.Start), 'f', -1, 32),
-			)
+	if g.c.RotationMode == ForceRotation {
+		rotation, _ := normalizeRotation(g.c.Rotation)
+
+		switch rotation {
+		case 90:
+			args = append(args, "-vf", "transpose=clock")
+		case 180:
+			args = append(args, "-vf", "hflip,vflip")
+		case 270:
+			args = append(args, "-vf", "transpose=cclock")
 		}
+	}
 
+	if g.r != nil {
 		if g.r.Duration > 0 {
 			args = append(args,
 				"-t",
@@ -335,42 +464,63 @@ func (g *FfmpegRGBAStream) Info() *FrameReaderInfo {
 }
 
 func (g *FfmpegRGBAStream) ReadFrame() (*Frame, error) {
+	var f *Frame
+	if g.c.Pooled {
+		if g.pool == nil {
+			g.pool = NewFramePool(g.i.Width, g.i.Height)
+		}
+		f = g.pool.Get()
+	} else {
+		f = &Frame{}
+	}
+
+	if err := g.ReadFrameInto(f); err != nil {
+		f.Release()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// ReadFrameInto reads the next frame into f. The data of f is reused when it is large enough.
+func (g *FfmpegRGBAStream) ReadFrameInto(f *Frame) error {
 	if g.r != nil && g.r.Duration == 0 {
-		return nil, io.EOF
+		return io.EOF
 	}
 
 	if !g.opened {
 		if err := g.open(); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	frameBytes := make([]byte, GlobalConfig.FramePixelDepth*g.i.Width*g.i.Height)
-	frameIndex := int(g.offset / int64(len(frameBytes)))
+	frameSize := GlobalConfig.FramePixelDepth * g.i.Width * g.i.Height
+	frameIndex := int(g.offset / int64(frameSize))
 
 	time := float32(frameIndex) * float32(1./g.i.FrameRate)
 
-	if time > g.r.Duration {
-		return nil, io.EOF
+	if g.r != nil && time > g.r.Duration {
+		return io.EOF
+	}
+
+	if cap(f.Data) < frameSize {
+		f.Data = make([]byte, frameSize)
 	}
+	f.Data = f.Data[:frameSize]
 
-	r, err := io.ReadFull(g.stdout, frameBytes)
+	r, err := io.ReadFull(g.stdout, f.Data)
 
 	if err != nil {
 		if r == 0 { //got invalid file descriptor (ffmpeg autocloses stdout?)
 			err = io.EOF
 		}
 
-		return nil, err
+		return err
 	}
 
 	g.offset += int64(r)
 
-	return &Frame{
-		Data:   frameBytes,
-		Width:  g.i.Width,
-		Height: g.i.Height,
-		Index:  frameIndex,
-		Time:   time,
-	}, nil
+	f.Width, f.Height, f.Index, f.Time = g.i.Width, g.i.Height, frameIndex, time
+
+	return nil
 }
@@ -18,3 +18,38 @@ func TestOpenVideo(t *testing.T) {
 		t.Fatal("Could not open video")
 	}
 }
+
+func TestPooledPCMFormatChange(t *testing.T) {
+	path := os.Getenv("GOMOVIE_VIDEO")
+	if path == "" {
+		t.Fatal("GOMOVIE_VIDEO not set!")
+	}
+
+	vid, err := gomovie.FfmpegOpenWithConfig(path, gomovie.OpenConfig{Pooled: true})
+	if err != nil {
+		t.Fatal("Could not open video")
+	}
+	defer vid.Close()
+
+	reader := vid.SampleReader
+
+	sb, err := reader.ReadSampleBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb.Release()
+
+	//the pool should follow the new format
+	format := reader.SampleFormat()
+	format.BlockSize /= 2
+	format.Layout = gomovie.Planar
+
+	sb, err = reader.ReadSampleBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sb.Layout != gomovie.Planar || len(sb.Bytes()) > format.BlockSize {
+		t.Fatalf("Expected a planar block of at most %v bytes but got %+v with %v bytes", format.BlockSize, sb.SampleFormat, len(sb.Bytes()))
+	}
+}
@@ -19,6 +19,10 @@ type WriteConfig struct {
 	ProgressCallback  func(progress float32)
 	DebugFFmpegOutput bool
 
+	//SubtitleCodec is used to add the subtitles of a *Video as a soft subtitle track (for example mov_text or webvtt).
+	//Subtitles are not written when empty. Use NewSubtitleTransform to burn them in instead.
+	SubtitleCodec string
+
 	audioResultChan chan bool
 }
 
@@ -32,6 +36,22 @@ func createUniqueFifo() (string, error) {
 	return "", errors.New("Could not create a fifo name!")
 }
 
+func writeTempSubtitles(subtitles *Subtitles) (string, error) {
+	file, err := os.CreateTemp("", "gomovie_subtitles_*.srt")
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	if err = subtitles.WriteSRT(file); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
 func FfmpegWrite(path string, src interface{}, config WriteConfig) (err error) {
 	var (
 		frameReader     FrameReader
@@ -40,6 +60,7 @@ func FfmpegWrite(path string, src interface{}, config WriteConfig) (err error) {
 		totalFrames     float32
 		progressBuffer  *bytes.Buffer
 		audioResultChan chan bool
+		subtitles       *Subtitles
 		fifoName        string
 	)
 
@@ -65,6 +86,7 @@ func FfmpegWrite(path string, src interface{}, config WriteConfig) (err error) {
 	case *Video:
 		frameReader = t.FrameReader
 		sampleReader = t.SampleReader
+		subtitles = t.Subtitles
 
 	default:
 		return errors.New("Can't write given object. It should implement FrameReader or SampleReader. Or it should be of type *Video")
@@ -87,12 +109,12 @@ func FfmpegWrite(path string, src interface{}, config WriteConfig) (err error) {
 	//audio has been specified
 	if sampleReader != nil {
 		audioInfo := sampleReader.Info()
-		sampleFormat := fmt.Sprintf("s%vle", sampleReader.SampleFormat().Depth)
+		sampleFormat := sampleReader.SampleFormat().FfmpegFormat()
 
 		args = append(args,
 			"-f", sampleFormat,
 			"-ar", strconv.FormatInt(int64(audioInfo.SampleRate), 10),
-			"-ac", strconv.FormatInt(int64(audioInfo.Channels), 2),
+			"-ac", strconv.FormatInt(int64(audioInfo.Channels), 10),
 		)
 
 		if frameReader != nil {
@@ -116,6 +138,32 @@ func FfmpegWrite(path string, src interface{}, config WriteConfig) (err error) {
 
 	}
 
+	//subtitles are passed as srt file and mapped together with the other inputs
+	if subtitles != nil && config.SubtitleCodec != "" {
+		var subtitlePath string
+		if subtitlePath, err = writeTempSubtitles(subtitles); err != nil {
+			return
+		}
+
+		defer os.Remove(subtitlePath)
+
+		inputs := 0
+		if frameReader != nil {
+			inputs++
+		}
+		if sampleReader != nil {
+			inputs++
+		}
+
+		args = append(args, "-i", subtitlePath)
+
+		for i := 0; i <= inputs; i++ {
+			args = append(args, "-map", strconv.Itoa(i))
+		}
+
+		args = append(args, "-scodec", config.SubtitleCodec)
+	}
+
 	if frameReader != nil {
 		//output format
 		args = append(args, "-pix_fmt", "yuv420p")
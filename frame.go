@@ -11,7 +11,13 @@ type Frame struct {
 	Width  int
 	Height int
 	Index  int
-	Time   float32
+
+	//Time in seconds relative to the start of the reader. Starts at 0 after a Slice.
+	Time float32
+
+	//set for frames from a FramePool
+	pool     *FramePool
+	released bool
 }
 
 //ToNRGBAImage converts the frame to a image.NRGBA
@@ -33,6 +39,13 @@ type FrameReaderInfo struct {
 	Duration  float32
 }
 
+//DisplayRotation returns the clockwise rotation (0, 90, 180 or 270) which is needed to display the stored frames upright.
+//The Rotation field uses the counter clockwise convention of the display matrix.
+func (i *FrameReaderInfo) DisplayRotation() int {
+	r, _ := normalizeRotation(-i.Rotation)
+	return r
+}
+
 //FrameReader describes an interface to read frames from a video
 type FrameReader interface {
 	io.ReadCloser
@@ -0,0 +1,317 @@
+package gomovie
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+)
+
+// generatorFrameReader creates each frame with a draw function. Static generators only draw the first frame and copy it for the next frames.
+// The draw function gets the time of the frame in the source which is different from the Time of the frame after a Slice.
+type generatorFrameReader struct {
+	i *FrameReaderInfo
+	r *Range
+
+	static bool
+	draw   func(f *Frame, t float32)
+
+	buf        []byte
+	l          []byte
+	frameIndex int
+}
+
+func newGenerator(info *FrameReaderInfo, static bool, draw func(f *Frame, t float32)) FrameReader {
+	return &generatorFrameReader{i: info, static: static, draw: draw}
+}
+
+func (src *generatorFrameReader) Range() *Range          { return src.r }
+func (src *generatorFrameReader) Info() *FrameReaderInfo { return src.i }
+func (src *generatorFrameReader) Close() error           { return nil }
+
+func (src *generatorFrameReader) Slice(r *Range) FrameReader {
+	r = r.Intersection(&Range{Start: 0, Duration: frameReaderDuration(src)})
+	r.parent = src.r
+	return &generatorFrameReader{i: src.i, r: r, static: src.static, draw: src.draw}
+}
+
+func (src *generatorFrameReader) frameCount() int {
+	return int(math.Floor(float64(frameReaderDuration(src)*src.i.FrameRate) + 1e-3))
+}
+
+// next sets the size, index and time of the next frame and returns the time in the source
+func (src *generatorFrameReader) next(f *Frame) (float32, error) {
+	if src.frameIndex >= src.frameCount() {
+		return 0, io.EOF
+	}
+
+	var start float32
+	if src.r != nil {
+		start = src.r.AbsStart()
+	}
+
+	f.Width, f.Height = src.i.Width, src.i.Height
+	f.Index = src.frameIndex
+	f.Time = float32(src.frameIndex) / src.i.FrameRate
+
+	src.frameIndex++
+
+	return start + f.Time, nil
+}
+
+func (src *generatorFrameReader) ReadFrame() (*Frame, error) {
+	f := &Frame{}
+
+	t, err := src.next(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if src.static && src.buf != nil {
+		f.Data = append([]byte(nil), src.buf...)
+		return f, nil
+	}
+
+	f.Data = make([]byte, f.Width*f.Height*4)
+	src.draw(f, t)
+
+	if src.static {
+		src.buf = append([]byte(nil), f.Data...)
+	}
+
+	return f, nil
+}
+
+// ReadFrameInto draws the next frame into f. The data of f is reused when it is large enough.
+func (src *generatorFrameReader) ReadFrameInto(f *Frame) error {
+	t, err := src.next(f)
+	if err != nil {
+		return err
+	}
+
+	f.Data = frameData(f.Data, f.Width, f.Height)
+
+	if src.static && src.buf != nil {
+		copy(f.Data, src.buf)
+		return nil
+	}
+
+	//the draw functions expect cleared data
+	for i := range f.Data {
+		f.Data[i] = 0
+	}
+	src.draw(f, t)
+
+	if src.static {
+		src.buf = append([]byte(nil), f.Data...)
+	}
+
+	return nil
+}
+
+func (src *generatorFrameReader) Read(p []byte) (n int, err error) {
+	if len(src.l) == 0 {
+		var f *Frame
+		if f, err = src.ReadFrame(); err != nil {
+			return
+		}
+		src.l = f.Data
+	}
+
+	n = copy(p, src.l)
+	src.l = src.l[n:]
+	return
+}
+
+// sizedInfo returns a copy of the info with the missing width and height taken from the bounds
+func sizedInfo(info *FrameReaderInfo, b image.Rectangle) *FrameReaderInfo {
+	i := *info
+	if i.Width == 0 || i.Height == 0 {
+		i.Width, i.Height = b.Dx(), b.Dy()
+	}
+	return &i
+}
+
+// NewImageClip creates a FrameReader which shows the image for the duration of info.
+// When the Width or Height of info is 0 the size of the image is used. Otherwise the image is fitted using DefaultFit.
+func NewImageClip(img image.Image, info *FrameReaderInfo) FrameReader {
+	b := img.Bounds()
+	info = sizedInfo(info, b)
+
+	return newGenerator(info, true, func(f *Frame, _ float32) {
+		src := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+		copy(f.Data, DefaultFit.apply(src.Pix, b.Dx(), b.Dy(), f.Width, f.Height))
+	})
+}
+
+// NewColorClip creates a FrameReader with frames of a single color
+func NewColorClip(c color.Color, info *FrameReaderInfo) FrameReader {
+	return newGenerator(info, true, func(f *Frame, _ float32) {
+		fillRGBA(f.Data, color.NRGBAModel.Convert(c).(color.NRGBA))
+	})
+}
+
+// NewGradientClip creates a FrameReader with a linear gradient from one color to another.
+// An angle of 0 degrees goes from left to right, 90 degrees from top to bottom.
+func NewGradientClip(from, to color.Color, angle float64, info *FrameReaderInfo) FrameReader {
+	c0 := color.NRGBAModel.Convert(from).(color.NRGBA)
+	c1 := color.NRGBAModel.Convert(to).(color.NRGBA)
+
+	return newGenerator(info, true, func(f *Frame, _ float32) {
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+
+		//project the corners on the direction to find the length of the gradient
+		length := math.Abs(cos)*float64(f.Width) + math.Abs(sin)*float64(f.Height)
+		cx, cy := float64(f.Width)/2, float64(f.Height)/2
+
+		mix := func(a, b uint8, p float64) uint8 {
+			return clampUint8(float32(float64(a) + (float64(b)-float64(a))*p))
+		}
+
+		for y := 0; y < f.Height; y++ {
+			for x := 0; x < f.Width; x++ {
+				p := ((float64(x)+.5-cx)*cos+(float64(y)+.5-cy)*sin)/length + .5
+
+				o := (y*f.Width + x) * 4
+				f.Data[o] = mix(c0.R, c1.R, p)
+				f.Data[o+1] = mix(c0.G, c1.G, p)
+				f.Data[o+2] = mix(c0.B, c1.B, p)
+				f.Data[o+3] = mix(c0.A, c1.A, p)
+			}
+		}
+	})
+}
+
+// NewCheckerboardClip creates a FrameReader with a checkerboard of squares with the given size in pixels
+func NewCheckerboardClip(size int, c1, c2 color.Color, info *FrameReaderInfo) FrameReader {
+	return newGenerator(info, true, func(f *Frame, _ float32) {
+		drawCheckerboard(f, size, color.NRGBAModel.Convert(c1).(color.NRGBA), color.NRGBAModel.Convert(c2).(color.NRGBA))
+	})
+}
+
+func drawCheckerboard(f *Frame, size int, c1, c2 color.NRGBA) {
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			c := c1
+			if (x/size+y/size)%2 == 1 {
+				c = c2
+			}
+
+			o := (y*f.Width + x) * 4
+			f.Data[o], f.Data[o+1], f.Data[o+2], f.Data[o+3] = c.R, c.G, c.B, c.A
+		}
+	}
+}
+
+// NewColorBarsClip creates a FrameReader with SMPTE color bars
+func NewColorBarsClip(info *FrameReaderInfo) FrameReader {
+	return newGenerator(info, true, func(f *Frame, _ float32) { drawColorBars(f) })
+}
+
+func drawColorBars(f *Frame) {
+	const (
+		l = 191 //75%
+		d = 19  //7.5% setup
+	)
+
+	top := []color.NRGBA{
+		{l, l, l, 255}, {l, l, 0, 255}, {0, l, l, 255}, {0, l, 0, 255}, {l, 0, l, 255}, {l, 0, 0, 255}, {0, 0, l, 255},
+	}
+
+	middle := []color.NRGBA{
+		{0, 0, l, 255}, {d, d, d, 255}, {l, 0, l, 255}, {d, d, d, 255}, {0, l, l, 255}, {d, d, d, 255}, {l, l, l, 255},
+	}
+
+	//-I, white, +Q, black, pluge (below black, black, above black), black
+	bottom := []struct {
+		c     color.NRGBA
+		width float64
+	}{
+		{color.NRGBA{0, 33, 76, 255}, 5. / 28},
+		{color.NRGBA{255, 255, 255, 255}, 5. / 28},
+		{color.NRGBA{50, 0, 106, 255}, 5. / 28},
+		{color.NRGBA{d, d, d, 255}, 5. / 28},
+		{color.NRGBA{9, 9, 9, 255}, 1. / 21},
+		{color.NRGBA{d, d, d, 255}, 1. / 21},
+		{color.NRGBA{29, 29, 29, 255}, 1. / 21},
+		{color.NRGBA{d, d, d, 255}, 1. / 7},
+	}
+
+	topHeight := f.Height * 2 / 3
+	middleHeight := f.Height * 3 / 4
+
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			var c color.NRGBA
+
+			switch {
+			case y < topHeight:
+				c = top[x*7/f.Width]
+			case y < middleHeight:
+				c = middle[x*7/f.Width]
+			default:
+				p := (float64(x) + .5) / float64(f.Width)
+				for _, b := range bottom {
+					c = b.c
+					if p -= b.width; p < 0 {
+						break
+					}
+				}
+			}
+
+			o := (y*f.Width + x) * 4
+			f.Data[o], f.Data[o+1], f.Data[o+2], f.Data[o+3] = c.R, c.G, c.B, c.A
+		}
+	}
+}
+
+// Timecode formats the time as HH:MM:SS:FF
+func Timecode(t float32, frameRate float32) string {
+	fps := int(math.Ceil(float64(frameRate)))
+	frames := int(math.Floor(float64(t*frameRate) + 1e-3))
+	secs := frames / fps
+
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", secs/3600, secs/60%60, secs%60, frames%fps)
+}
+
+// NewTestPatternClip creates a FrameReader with color bars, a moving box and the frame number and timecode burnt in.
+// The numbers are based on the time in the source so they stay correct after a Slice.
+func NewTestPatternClip(info *FrameReaderInfo) FrameReader {
+	bars := make([]byte, info.Width*info.Height*4)
+	drawColorBars(&Frame{Data: bars, Width: info.Width, Height: info.Height})
+
+	//scale the bitmap font with the size of the frame
+	scale := intMax(info.Height/180, 1)
+	style := TextStyle{Background: color.Black, Padding: 2}
+
+	box := intMax(info.Height/10, 2)
+
+	return newGenerator(info, false, func(f *Frame, t float32) {
+		copy(f.Data, bars)
+
+		//box which moves from left to right in 2 seconds
+		p := math.Mod(float64(t), 2) / 2
+		bx := int(p * float64(f.Width-box))
+		by := f.Height*2/3 - box
+
+		for y := intMax(by, 0); y < by+box && y < f.Height; y++ {
+			for x := bx; x < bx+box && x < f.Width; x++ {
+				o := (y*f.Width + x) * 4
+				f.Data[o], f.Data[o+1], f.Data[o+2], f.Data[o+3] = 255, 255, 255, 255
+			}
+		}
+
+		frameNumber := int(math.Floor(float64(t*info.FrameRate) + 1e-3))
+		text := RenderText(fmt.Sprintf("%s\nframe %d", Timecode(t, info.FrameRate), frameNumber), style)
+
+		b := text.Bounds()
+		w, h := b.Dx()*scale, b.Dy()*scale
+		pix := resizeRGBA(text.Pix, b.Dx(), b.Dy(), w, h, NearestNeighbor)
+
+		drawOverRGBA(f.Data, f.Width, f.Height, pix, w, h, (f.Width-w)/2, f.Height/3-h/2, 255)
+	})
+}
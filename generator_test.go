@@ -0,0 +1,113 @@
+package gomovie_test
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestColorClipSlice(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 16, Height: 8, FrameRate: 25, Duration: 4}
+	clip := gomovie.NewColorClip(color.NRGBA{0, 255, 0, 255}, info)
+
+	sliced := clip.Slice(&gomovie.Range{Start: 1, Duration: 2}).Slice(&gomovie.Range{Start: .5, Duration: 10})
+
+	var count int
+	for {
+		f, err := sliced.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//the time is relative to the start of the slice
+		if count == 0 && f.Time != 0 {
+			t.Fatalf("Expected first frame at 0 but got %v", f.Time)
+		}
+
+		if c := f.ToNRGBAImage().NRGBAAt(3, 3); c != (color.NRGBA{0, 255, 0, 255}) {
+			t.Fatalf("Unexpected color %v", c)
+		}
+		count++
+	}
+
+	if count != 37 {
+		t.Fatalf("Expected 37 frames but got %v", count)
+	}
+}
+
+func TestColorClipOwnData(t *testing.T) {
+	clip := gomovie.NewColorClip(color.NRGBA{0, 255, 0, 255}, &gomovie.FrameReaderInfo{Width: 4, Height: 4, FrameRate: 25, Duration: 1})
+
+	first, err := clip.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//a transform modifies the frame in place
+	for i := range first.Data {
+		first.Data[i] = 0
+	}
+
+	second, err := clip.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if second.Data[1] != 255 {
+		t.Fatalf("Expected the next frame to have its own data but got %v", second.Data[:4])
+	}
+}
+
+func TestImageClip(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 20))
+	clip := gomovie.NewImageClip(img, &gomovie.FrameReaderInfo{FrameRate: 25, Duration: 1})
+
+	if info := clip.Info(); info.Width != 10 || info.Height != 20 {
+		t.Fatalf("Expected the size of the image but got %vx%v", info.Width, info.Height)
+	}
+}
+
+func TestTestPattern(t *testing.T) {
+	clip := gomovie.NewTestPatternClip(&gomovie.FrameReaderInfo{Width: 320, Height: 180, FrameRate: 25, Duration: 1})
+
+	f1, _ := clip.ReadFrame()
+	d1 := append([]byte(nil), f1.Data...)
+	f2, _ := clip.ReadFrame()
+
+	if string(d1) == string(f2.Data) {
+		t.Fatal("Expected the test pattern to change every frame")
+	}
+
+	if tc := gomovie.Timecode(61.5, 25); tc != "00:01:01:12" {
+		t.Fatalf("Unexpected timecode %v", tc)
+	}
+}
+
+func TestTestPatternSlice(t *testing.T) {
+	clip := gomovie.NewTestPatternClip(&gomovie.FrameReaderInfo{Width: 320, Height: 180, FrameRate: 25, Duration: 2})
+
+	var whole *gomovie.Frame
+	for i := 0; i <= 25; i++ {
+		whole, _ = clip.ReadFrame()
+	}
+
+	f, err := clip.Slice(&gomovie.Range{Start: 1, Duration: 1}).ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Time != 0 {
+		t.Fatalf("Expected the first frame of the slice at 0 but got %v", f.Time)
+	}
+
+	//the pattern is drawn at the time in the source
+	if string(f.Data) != string(whole.Data) {
+		t.Fatalf("Expected the slice to show the pattern at 1 second")
+	}
+}
@@ -59,10 +59,27 @@ func (r *Range) AbsStart() float32 {
 type Video struct {
 	FrameReader
 	SampleReader
+
+	//Subtitles are optional. Their timing follows Slice and Concat.
+	Subtitles *Subtitles
 }
 
 func (v *Video) Slice(r *Range) (*Video, error) {
-	return &Video{v.FrameReader.Slice(r), v.SampleReader.Slice(r)}, nil
+	s := new(Video)
+
+	if v.FrameReader != nil {
+		s.FrameReader = v.FrameReader.Slice(r)
+	}
+
+	if v.SampleReader != nil {
+		s.SampleReader = v.SampleReader.Slice(r)
+	}
+
+	if v.Subtitles != nil {
+		s.Subtitles = v.Subtitles.Slice(r)
+	}
+
+	return s, nil
 }
 
 //Info returns information about the FrameReader and SampleReader
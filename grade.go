@@ -0,0 +1,305 @@
+package gomovie
+
+import (
+	"math"
+	"sort"
+)
+
+// keyframesOr returns the value of the keyframes at t or def when there are no keyframes
+func keyframesOr(k Keyframes, t float32, def float64) float64 {
+	if len(k) == 0 {
+		return def
+	}
+	return k.At(t)
+}
+
+// channelTables maps the 256 values of the red, green and blue channel
+type channelTables [3][256]uint8
+
+// newChannelTables creates tables from a function which maps a value (0 - 1) of a channel
+func newChannelTables(fn func(channel int, v float64) float64) *channelTables {
+	var t channelTables
+	for c := 0; c < 3; c++ {
+		for i := 0; i < 256; i++ {
+			t[c][i] = clampUint8(float32(fn(c, float64(i)/255) * 255))
+		}
+	}
+	return &t
+}
+
+// apply maps the rgb channels of the data through the tables. The alpha is left unchanged.
+func (t *channelTables) apply(data []byte) {
+	for i := 0; i+4 <= len(data); i += 4 {
+		data[i] = t[0][data[i]]
+		data[i+1] = t[1][data[i+1]]
+		data[i+2] = t[2][data[i+2]]
+	}
+}
+
+// ColorAdjust describes the basic color corrections of NewColorAdjustTransform. Empty keyframes leave that property unchanged.
+type ColorAdjust struct {
+	// Brightness from -1 to 1 is added to each channel
+	Brightness Keyframes
+
+	// Contrast around mid gray. 1 is unchanged, 0 is flat gray.
+	Contrast Keyframes
+
+	// Saturation. 1 is unchanged, 0 is grayscale.
+	Saturation Keyframes
+
+	// Gamma. 1 is unchanged, above 1 brightens the mid tones.
+	Gamma Keyframes
+
+	// Hue rotation in degrees
+	Hue Keyframes
+}
+
+// hueSaturationMatrix returns the color matrix which rotates the hue around the luma axis and scales the saturation
+func hueSaturationMatrix(degrees, saturation float64) [9]float64 {
+	const lr, lg, lb = .213, .715, .072
+
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+
+	hue := [9]float64{
+		lr + cos*(1-lr) - sin*lr, lg - cos*lg - sin*lg, lb - cos*lb + sin*(1-lb),
+		lr - cos*lr + sin*.143, lg + cos*(1-lg) + sin*.14, lb - cos*lb - sin*.283,
+		lr - cos*lr - sin*(1-lr), lg - cos*lg + sin*lg, lb + cos*(1-lb) + sin*lb,
+	}
+
+	s := saturation
+	sat := [9]float64{
+		lr + (1-lr)*s, lg - lg*s, lb - lb*s,
+		lr - lr*s, lg + (1-lg)*s, lb - lb*s,
+		lr - lr*s, lg - lg*s, lb + (1-lb)*s,
+	}
+
+	var m [9]float64
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			for k := 0; k < 3; k++ {
+				m[r*3+c] += sat[r*3+k] * hue[k*3+c]
+			}
+		}
+	}
+	return m
+}
+
+// applyColorMatrix multiplies the red, green and blue of each pixel with the matrix in place
+func applyColorMatrix(data []byte, m [9]float64) {
+	for i := 0; i+4 <= len(data); i += 4 {
+		r, g, b := float64(data[i]), float64(data[i+1]), float64(data[i+2])
+		data[i] = clampUint8(float32(m[0]*r + m[1]*g + m[2]*b))
+		data[i+1] = clampUint8(float32(m[3]*r + m[4]*g + m[5]*b))
+		data[i+2] = clampUint8(float32(m[6]*r + m[7]*g + m[8]*b))
+	}
+}
+
+// NewColorAdjustTransform creates a FrameTransform for brightness, contrast, gamma, saturation and hue.
+// Brightness, contrast and gamma are applied first, then the saturation and hue.
+func NewColorAdjustTransform(a ColorAdjust) FrameTransform {
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			brightness := keyframesOr(a.Brightness, f.Time, 0)
+			contrast := keyframesOr(a.Contrast, f.Time, 1)
+			gamma := keyframesOr(a.Gamma, f.Time, 1)
+			saturation := keyframesOr(a.Saturation, f.Time, 1)
+			hue := keyframesOr(a.Hue, f.Time, 0)
+
+			tables := newChannelTables(func(_ int, v float64) float64 {
+				v = (v+brightness-.5)*contrast + .5
+				if gamma != 1 && v > 0 {
+					v = math.Pow(v, 1/gamma)
+				}
+				return v
+			})
+
+			tables.apply(f.Data)
+
+			if saturation != 1 || math.Mod(hue, 360) != 0 {
+				applyColorMatrix(f.Data, hueSaturationMatrix(hue, saturation))
+			}
+		},
+	}
+}
+
+// CurvePoint maps an input value to an output value. Both between 0 and 1.
+type CurvePoint struct {
+	In, Out float64
+}
+
+// Curve is a tone curve through points which are interpolated with a monotone cubic spline so the curve never overshoots.
+// An empty curve leaves the values unchanged.
+type Curve []CurvePoint
+
+// At returns the output of the curve for the input value v
+func (c Curve) At(v float64) float64 {
+	return c.interpolator()(v)
+}
+
+// interpolator returns the interpolation function of the points using the Fritsch-Carlson method
+func (c Curve) interpolator() func(v float64) float64 {
+	if len(c) == 0 {
+		return func(v float64) float64 { return v }
+	}
+
+	c = append(Curve(nil), c...)
+	sort.Slice(c, func(i, j int) bool { return c[i].In < c[j].In })
+
+	n := len(c)
+	if n == 1 {
+		return func(float64) float64 { return c[0].Out }
+	}
+
+	slopes := make([]float64, n-1)
+	for i := range slopes {
+		dx := c[i+1].In - c[i].In
+		if dx > 0 {
+			slopes[i] = (c[i+1].Out - c[i].Out) / dx
+		}
+	}
+
+	m := make([]float64, n)
+	m[0], m[n-1] = slopes[0], slopes[n-2]
+	for i := 1; i < n-1; i++ {
+		if slopes[i-1]*slopes[i] <= 0 {
+			continue
+		}
+		m[i] = (slopes[i-1] + slopes[i]) / 2
+	}
+
+	for i, s := range slopes {
+		if s == 0 {
+			m[i], m[i+1] = 0, 0
+			continue
+		}
+		a, b := m[i]/s, m[i+1]/s
+		if h := a*a + b*b; h > 9 {
+			t := 3 / math.Sqrt(h)
+			m[i], m[i+1] = t*a*s, t*b*s
+		}
+	}
+
+	return func(v float64) float64 {
+		if v <= c[0].In {
+			return c[0].Out
+		}
+		if v >= c[n-1].In {
+			return c[n-1].Out
+		}
+
+		i := sort.Search(n, func(i int) bool { return c[i].In > v }) - 1
+		dx := c[i+1].In - c[i].In
+		t := (v - c[i].In) / dx
+
+		//cubic hermite basis
+		t2, t3 := t*t, t*t*t
+		return (2*t3-3*t2+1)*c[i].Out + (t3-2*t2+t)*dx*m[i] + (-2*t3+3*t2)*c[i+1].Out + (t3-t2)*dx*m[i+1]
+	}
+}
+
+// Curves describes the tone curves of NewCurvesTransform. Master is applied after the curve of the channel.
+type Curves struct {
+	Master, Red, Green, Blue Curve
+}
+
+// NewCurvesTransform creates a FrameTransform which applies tone curves to the red, green and blue channel
+func NewCurvesTransform(c Curves) FrameTransform {
+	master := c.Master.interpolator()
+	channels := [3]func(float64) float64{c.Red.interpolator(), c.Green.interpolator(), c.Blue.interpolator()}
+
+	tables := newChannelTables(func(ch int, v float64) float64 {
+		return master(channels[ch](v))
+	})
+
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			tables.apply(f.Data)
+		},
+	}
+}
+
+// Levels describes the input and output range of NewLevelsTransform. All values are between 0 and 1.
+type Levels struct {
+	// InBlack and InWhite are stretched to OutBlack and OutWhite
+	InBlack, InWhite float64
+
+	// Gamma of the mid tones. 1 is unchanged, above 1 brightens.
+	Gamma float64
+
+	OutBlack, OutWhite float64
+}
+
+// NewLevels creates Levels which leave the values unchanged
+func NewLevels() Levels {
+	return Levels{InWhite: 1, Gamma: 1, OutWhite: 1}
+}
+
+// NewLevelsTransform creates a FrameTransform which remaps the levels of the red, green and blue channel
+func NewLevelsTransform(l Levels) FrameTransform {
+	tables := newChannelTables(func(_ int, v float64) float64 {
+		v = (v - l.InBlack) / math.Max(l.InWhite-l.InBlack, 1e-6)
+		v = math.Max(math.Min(v, 1), 0)
+		if l.Gamma > 0 && l.Gamma != 1 {
+			v = math.Pow(v, 1/l.Gamma)
+		}
+		return l.OutBlack + v*(l.OutWhite-l.OutBlack)
+	})
+
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			tables.apply(f.Data)
+		},
+	}
+}
+
+// kelvinToRGB returns the color (0 - 1) of a black body at the temperature in Kelvin (1000 - 40000)
+func kelvinToRGB(kelvin float64) [3]float64 {
+	t := math.Max(math.Min(kelvin, 40000), 1000) / 100
+
+	var r, g, b float64
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -.0755148492)
+	}
+
+	switch {
+	case t >= 66:
+		b = 255
+	case t <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+
+	clamp := func(v float64) float64 { return math.Max(math.Min(v, 255), 0) / 255 }
+	return [3]float64{clamp(r), clamp(g), clamp(b)}
+}
+
+// NewWhiteBalanceTransform creates a FrameTransform which corrects the color of the light. Temperature is the color
+// temperature in Kelvin of the light in the footage. 6500 is neutral, lower values correct warm light by cooling the frame.
+// Tint from -1 to 1 corrects a green (negative) or magenta (positive) cast. The gains are normalized so the luma of white stays the same.
+func NewWhiteBalanceTransform(temperature, tint Keyframes) FrameTransform {
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			light := kelvinToRGB(keyframesOr(temperature, f.Time, 6500))
+			neutral := kelvinToRGB(6500)
+
+			var gains [3]float64
+			for c := range gains {
+				gains[c] = neutral[c] / math.Max(light[c], 1e-3)
+			}
+			gains[1] *= 1 + keyframesOr(tint, f.Time, 0)*.5
+
+			luma := .299*gains[0] + .587*gains[1] + .114*gains[2]
+
+			tables := newChannelTables(func(c int, v float64) float64 {
+				return v * gains[c] / luma
+			})
+
+			tables.apply(f.Data)
+		},
+	}
+}
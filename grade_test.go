@@ -0,0 +1,88 @@
+package gomovie_test
+
+import (
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+// pixelFrame creates a 1x1 frame
+func pixelFrame(r, g, b uint8) *gomovie.Frame {
+	return &gomovie.Frame{Data: []byte{r, g, b, 255}, Width: 1, Height: 1}
+}
+
+func TestColorAdjustTransform(t *testing.T) {
+	f := pixelFrame(200, 100, 50)
+	gomovie.NewColorAdjustTransform(gomovie.ColorAdjust{}).Transform(f)
+	if f.Data[0] != 200 || f.Data[1] != 100 || f.Data[2] != 50 {
+		t.Fatalf("Expected an unchanged pixel but got %v", f.Data)
+	}
+
+	data := []byte{200, 100, 50, 255}
+	f = &gomovie.Frame{Data: data, Width: 1, Height: 1}
+	gomovie.NewColorAdjustTransform(gomovie.ColorAdjust{Saturation: gomovie.Constant(0)}).Transform(f)
+	if f.Data[0] != f.Data[1] || f.Data[1] != f.Data[2] {
+		t.Fatalf("Expected gray but got %v", f.Data)
+	}
+
+	f = pixelFrame(100, 100, 100)
+	gomovie.NewColorAdjustTransform(gomovie.ColorAdjust{Brightness: gomovie.Constant(.2), Contrast: gomovie.Constant(2)}).Transform(f)
+	if f.Data[0] <= 100 {
+		t.Fatalf("Expected a brighter pixel but got %v", f.Data)
+	}
+
+	f = pixelFrame(255, 0, 0)
+	gomovie.NewColorAdjustTransform(gomovie.ColorAdjust{Hue: gomovie.Constant(120)}).Transform(f)
+	if f.Data[1] <= f.Data[0] || f.Data[1] <= f.Data[2] {
+		t.Fatalf("Expected red to rotate to green but got %v", f.Data)
+	}
+}
+
+func TestCurvesTransform(t *testing.T) {
+	curve := gomovie.Curve{{In: 0, Out: 0}, {In: .25, Out: .15}, {In: .75, Out: .85}, {In: 1, Out: 1}}
+
+	prev := -1.
+	for v := 0.; v <= 1; v += .01 {
+		out := curve.At(v)
+		if out < prev || out < 0 || out > 1 {
+			t.Fatalf("Expected a monotone curve but got %v at %v", out, v)
+		}
+		prev = out
+	}
+
+	if v := curve.At(.25); !near(float32(v), .15) {
+		t.Fatalf("Expected the curve through its points but got %v", v)
+	}
+
+	f := pixelFrame(128, 64, 64)
+	gomovie.NewCurvesTransform(gomovie.Curves{Red: gomovie.Curve{{In: 0, Out: 1}, {In: 1, Out: 0}}}).Transform(f)
+	if f.Data[0] != 127 || f.Data[1] != 64 {
+		t.Fatalf("Expected an inverted red channel but got %v", f.Data)
+	}
+}
+
+func TestLevelsTransform(t *testing.T) {
+	l := gomovie.NewLevels()
+	l.InBlack, l.InWhite = .2, .8
+
+	f := &gomovie.Frame{Data: []byte{51, 204, 128, 255}, Width: 1, Height: 1}
+	gomovie.NewLevelsTransform(l).Transform(f)
+	if f.Data[0] != 0 || f.Data[1] != 255 || f.Data[2] != 128 {
+		t.Fatalf("Expected stretched levels but got %v", f.Data)
+	}
+}
+
+func TestWhiteBalanceTransform(t *testing.T) {
+	f := pixelFrame(128, 128, 128)
+	gomovie.NewWhiteBalanceTransform(nil, nil).Transform(f)
+	if f.Data[0] != 128 || f.Data[2] != 128 {
+		t.Fatalf("Expected an unchanged pixel but got %v", f.Data)
+	}
+
+	//footage under tungsten light is corrected towards blue
+	f = pixelFrame(128, 128, 128)
+	gomovie.NewWhiteBalanceTransform(gomovie.Constant(3200), nil).Transform(f)
+	if f.Data[2] <= f.Data[0] {
+		t.Fatalf("Expected a cooler pixel but got %v", f.Data)
+	}
+}
@@ -0,0 +1,408 @@
+package gomovie
+
+import (
+	"io"
+	"math"
+	"sort"
+)
+
+// biquad is a second order iir filter in direct form 1
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting returns the two stages of the K-weighting filter of ITU-R BS.1770 for the sample rate
+func kWeighting(rate int) [2]biquad {
+	//high shelf which models the acoustic effect of the head
+	f0, g, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / float64(rate))
+	vh := math.Pow(10, g/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	//high pass
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / float64(rate))
+	a0 = 1 + k/q + k*k
+
+	highPass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	return [2]biquad{shelf, highPass}
+}
+
+// loudnessChannelWeight returns the weight of the channel in the sum. For 5.1 (FL FR FC LFE BL BR) the LFE is ignored and the surrounds are weighted +1.5 dB.
+func loudnessChannelWeight(channel, channels int) float64 {
+	if channels == 6 {
+		switch channel {
+		case 3:
+			return 0
+		case 4, 5:
+			return 1.41
+		}
+	}
+	return 1
+}
+
+func energyToLoudness(e float64) float64 {
+	return -0.691 + 10*math.Log10(e)
+}
+
+const (
+	// steps of the momentary and short-term curves in seconds
+	LoudnessInterval = .1
+
+	momentaryBlocks = 4  //400 ms
+	shortTermBlocks = 30 //3 s
+
+	absoluteGate = -70.
+)
+
+// truePeakTaps is the number of input samples used for each oversampled value
+const truePeakTaps = 12
+
+// LoudnessMeter measures the loudness of interleaved float frames according to EBU R128 (ITU-R BS.1770).
+// Loudness values are in LUFS. Silence is -Inf.
+type LoudnessMeter struct {
+	channels int
+
+	filters [][2]biquad
+	weights []float64
+
+	blockSize int //frames in a 100 ms block
+	sum       float64
+	count     int
+	energies  []float64 //mean weighted square of each 100 ms block
+
+	oversample int
+	phases     [][]float64
+	history    [][]float64
+	truePeak   float64
+	samplePeak float64
+}
+
+// NewLoudnessMeter creates a LoudnessMeter for frames with the sample rate and number of channels
+func NewLoudnessMeter(sampleRate, channels int) *LoudnessMeter {
+	m := &LoudnessMeter{
+		channels:  channels,
+		filters:   make([][2]biquad, channels),
+		weights:   make([]float64, channels),
+		history:   make([][]float64, channels),
+		blockSize: intMax(int(float64(sampleRate)*LoudnessInterval+.5), 1),
+	}
+
+	for c := 0; c < channels; c++ {
+		m.filters[c] = kWeighting(sampleRate)
+		m.weights[c] = loudnessChannelWeight(c, channels)
+		m.history[c] = make([]float64, truePeakTaps)
+	}
+
+	//oversample to at least 192 kHz to find the peaks between the samples
+	m.oversample = 1
+	for sampleRate*m.oversample < 192000 && m.oversample < 4 {
+		m.oversample *= 2
+	}
+
+	m.phases = truePeakFilter(m.oversample)
+
+	return m
+}
+
+// truePeakFilter returns the polyphase coefficients of a kaiser windowed sinc interpolation filter
+func truePeakFilter(oversample int) [][]float64 {
+	phases := make([][]float64, oversample)
+	length := truePeakTaps * oversample
+	center := float64(length-1) / 2
+	i0beta := besselI0(6)
+
+	for j := 0; j < length; j++ {
+		x := (float64(j) - center) / center
+		v := sinc((float64(j)-center)/float64(oversample)) * besselI0(6*math.Sqrt(math.Max(0, 1-x*x))) / i0beta
+
+		p := j % oversample
+		phases[p] = append(phases[p], v)
+	}
+
+	return phases
+}
+
+// Write adds the interleaved frames to the measurement
+func (m *LoudnessMeter) Write(frames []float32) {
+	n := len(frames) / m.channels
+
+	for i := 0; i < n; i++ {
+		var e float64
+
+		for c := 0; c < m.channels; c++ {
+			x := float64(frames[i*m.channels+c])
+
+			m.measurePeak(c, x)
+
+			if m.weights[c] == 0 {
+				continue
+			}
+
+			f := &m.filters[c]
+			y := f[1].process(f[0].process(x))
+			e += m.weights[c] * y * y
+		}
+
+		m.sum += e
+		m.count++
+
+		if m.count == m.blockSize {
+			m.energies = append(m.energies, m.sum/float64(m.count))
+			m.sum, m.count = 0, 0
+		}
+	}
+}
+
+func (m *LoudnessMeter) measurePeak(c int, x float64) {
+	m.samplePeak = math.Max(m.samplePeak, math.Abs(x))
+
+	h := m.history[c]
+	copy(h, h[1:])
+	h[len(h)-1] = x
+
+	if m.oversample == 1 {
+		m.truePeak = m.samplePeak
+		return
+	}
+
+	for _, phase := range m.phases {
+		var y float64
+		for k, v := range phase {
+			y += h[len(h)-1-k] * v
+		}
+		m.truePeak = math.Max(m.truePeak, math.Abs(y))
+	}
+	m.truePeak = math.Max(m.truePeak, m.samplePeak)
+}
+
+// window returns the loudness of the last blocks ending at block end. Missing blocks at the start count as silence.
+func (m *LoudnessMeter) window(end, blocks int) float64 {
+	var e float64
+	for i := intMax(end-blocks, 0); i < end; i++ {
+		e += m.energies[i]
+	}
+	return energyToLoudness(e / float64(blocks))
+}
+
+// Momentary returns the loudness of the last 400 ms
+func (m *LoudnessMeter) Momentary() float64 {
+	return m.window(len(m.energies), momentaryBlocks)
+}
+
+// ShortTerm returns the loudness of the last 3 seconds
+func (m *LoudnessMeter) ShortTerm() float64 {
+	return m.window(len(m.energies), shortTermBlocks)
+}
+
+// windowEnergies returns the mean energy of every complete window
+func (m *LoudnessMeter) windowEnergies(blocks int) (out []float64) {
+	var e float64
+	for i, v := range m.energies {
+		e += v
+		if i >= blocks {
+			e -= m.energies[i-blocks]
+		}
+		if i >= blocks-1 {
+			out = append(out, e/float64(blocks))
+		}
+	}
+	return
+}
+
+// gate returns the energies above the absolute gate and the relative gate (in LU below the loudness of the energies above the absolute gate)
+func gate(energies []float64, relative float64) []float64 {
+	var (
+		abs []float64
+		sum float64
+	)
+
+	for _, e := range energies {
+		if energyToLoudness(e) > absoluteGate {
+			abs = append(abs, e)
+			sum += e
+		}
+	}
+
+	if len(abs) == 0 {
+		return nil
+	}
+
+	threshold := energyToLoudness(sum/float64(len(abs))) + relative
+
+	var out []float64
+	for _, e := range abs {
+		if energyToLoudness(e) > threshold {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Integrated returns the gated loudness of everything written so far
+func (m *LoudnessMeter) Integrated() float64 {
+	gated := gate(m.windowEnergies(momentaryBlocks), -10)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+
+	var sum float64
+	for _, e := range gated {
+		sum += e
+	}
+	return energyToLoudness(sum / float64(len(gated)))
+}
+
+// Range returns the loudness range (LRA) in LU. The spread between the 10th and 95th percentile of the gated short-term loudness.
+func (m *LoudnessMeter) Range() float64 {
+	gated := gate(m.windowEnergies(shortTermBlocks), -20)
+	if len(gated) == 0 {
+		return 0
+	}
+
+	sort.Float64s(gated)
+
+	percentile := func(p float64) float64 {
+		return energyToLoudness(gated[int(math.Floor(p*float64(len(gated)-1)+.5))])
+	}
+
+	return percentile(.95) - percentile(.1)
+}
+
+// TruePeak returns the highest (4 times oversampled) peak in dBTP
+func (m *LoudnessMeter) TruePeak() float64 {
+	return GainToDecibels(m.truePeak)
+}
+
+// LoudnessReport is the result of a loudness measurement
+type LoudnessReport struct {
+	// Integrated loudness in LUFS
+	Integrated float64
+
+	// Range is the loudness range in LU
+	Range float64
+
+	// TruePeak in dBTP
+	TruePeak float64
+
+	// SamplePeak in dBFS
+	SamplePeak float64
+
+	// Momentary (400 ms) and ShortTerm (3 s) loudness every LoudnessInterval seconds. Value i is the window ending at (i+1) * LoudnessInterval.
+	Momentary []float64
+	ShortTerm []float64
+}
+
+// Report returns a LoudnessReport with the measurement so far
+func (m *LoudnessMeter) Report() *LoudnessReport {
+	r := &LoudnessReport{
+		Integrated: m.Integrated(),
+		Range:      m.Range(),
+		TruePeak:   m.TruePeak(),
+		SamplePeak: GainToDecibels(m.samplePeak),
+		Momentary:  make([]float64, len(m.energies)),
+		ShortTerm:  make([]float64, len(m.energies)),
+	}
+
+	for i := range m.energies {
+		r.Momentary[i] = m.window(i+1, momentaryBlocks)
+		r.ShortTerm[i] = m.window(i+1, shortTermBlocks)
+	}
+
+	return r
+}
+
+// MeasureLoudness reads all the samples of the reader and returns the loudness
+func MeasureLoudness(reader SampleReader) (*LoudnessReport, error) {
+	info := reader.Info()
+	m := NewLoudnessMeter(info.SampleRate, info.Channels)
+
+	for {
+		sb, err := reader.ReadSampleBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.Write(sb.Floats())
+	}
+
+	return m.Report(), nil
+}
+
+// NormalizeConfig describes the loudness target of Normalize
+type NormalizeConfig struct {
+	// Target integrated loudness in LUFS
+	Target float64
+
+	// TruePeakCeiling in dBTP. The gain is lowered when the true peak would exceed it.
+	TruePeakCeiling float64
+}
+
+var (
+	// EBUR128Loudness is the broadcast target of EBU R128
+	EBUR128Loudness = NormalizeConfig{Target: -23, TruePeakCeiling: -1}
+
+	// PodcastLoudness is the target most podcast and streaming platforms require
+	PodcastLoudness = NormalizeConfig{Target: -16, TruePeakCeiling: -1}
+)
+
+// Gain returns the gain in dB which is needed to reach the target of the config. Silence is not amplified.
+func (r *LoudnessReport) Gain(config NormalizeConfig) float64 {
+	if math.IsInf(r.Integrated, -1) {
+		return 0
+	}
+
+	gain := config.Target - r.Integrated
+	if r.TruePeak+gain > config.TruePeakCeiling {
+		gain = config.TruePeakCeiling - r.TruePeak
+	}
+	return gain
+}
+
+// Normalize applies the gain to the reader which is needed to bring the measured loudness to the target of the config (single pass).
+// The report should be measured from the same audio, for example in an earlier run.
+func Normalize(reader SampleReader, report *LoudnessReport, config NormalizeConfig) *SampleTransformer {
+	gain := DecibelsToGain(report.Gain(config))
+
+	return NewSampleTransformer(reader).AddTransform(NewVolumeTransform(Constant(gain)))
+}
+
+// NormalizeTwoPass measures a slice of the whole reader first and then normalizes the reader.
+// The reader needs to support reading a slice independently, which is the case for ffmpeg streams and generators.
+func NormalizeTwoPass(reader SampleReader, config NormalizeConfig) (*SampleTransformer, *LoudnessReport, error) {
+	measure := reader.Slice(&Range{Start: 0, Duration: sampleReaderDuration(reader)})
+	defer measure.Close()
+
+	report, err := MeasureLoudness(measure)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return Normalize(reader, report, config), report, nil
+}
@@ -0,0 +1,75 @@
+package gomovie_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestMeasureLoudness(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 48000, Channels: 2, Duration: 5}
+	reader := gomovie.NewToneReader(gomovie.Sine, 1000, gomovie.DecibelsToGain(-20), info)
+
+	report, err := gomovie.MeasureLoudness(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//a stereo 1 kHz sine at -20 dBFS measures -20 LUFS
+	if math.Abs(report.Integrated+20) > .1 {
+		t.Fatalf("Expected -20 LUFS but got %v", report.Integrated)
+	}
+
+	if report.Range > .1 {
+		t.Fatalf("Expected no loudness range but got %v", report.Range)
+	}
+
+	if math.Abs(report.TruePeak+20) > .1 {
+		t.Fatalf("Expected a true peak of -20 dBTP but got %v", report.TruePeak)
+	}
+
+	if len(report.Momentary) != 50 {
+		t.Fatalf("Expected 50 momentary values but got %v", len(report.Momentary))
+	}
+}
+
+func TestMeasureLoudnessSilence(t *testing.T) {
+	reader := gomovie.NewNullSampleReader(&gomovie.SampleReaderInfo{SampleRate: 48000, Channels: 2, Duration: 1})
+
+	report, err := gomovie.MeasureLoudness(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !math.IsInf(report.Integrated, -1) {
+		t.Fatalf("Expected -Inf but got %v", report.Integrated)
+	}
+}
+
+func TestNormalizeTwoPass(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 48000, Channels: 2, Duration: 3}
+	reader := gomovie.NewToneReader(gomovie.Sine, 1000, gomovie.DecibelsToGain(-12), info)
+
+	normalized, _, err := gomovie.NormalizeTwoPass(reader, gomovie.EBUR128Loudness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := gomovie.MeasureLoudness(normalized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(report.Integrated+23) > .1 {
+		t.Fatalf("Expected -23 LUFS but got %v", report.Integrated)
+	}
+}
+
+func TestNormalizeTruePeakCeiling(t *testing.T) {
+	report := &gomovie.LoudnessReport{Integrated: -30, TruePeak: -3}
+
+	if g := report.Gain(gomovie.PodcastLoudness); g != 2 {
+		t.Fatalf("Expected the gain to be limited to 2 dB but got %v", g)
+	}
+}
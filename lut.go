@@ -0,0 +1,260 @@
+package gomovie
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	invalidCubeError       = errors.New("Invalid cube LUT")
+	unsupportedCube1DError = errors.New("1D cube LUTs are not supported")
+	missingCubeSizeError   = errors.New("Cube LUT has no LUT_3D_SIZE")
+	cubeSizeMismatchError  = errors.New("Cube LUT has the wrong number of entries for its size")
+)
+
+// LUT3D is a 3D color lookup table
+type LUT3D struct {
+	Title string
+
+	// Size is the number of entries along each axis
+	Size int
+
+	// DomainMin and DomainMax are the input range of the red, green and blue axis. Usually 0 to 1.
+	DomainMin, DomainMax [3]float64
+
+	// Table has Size^3 output colors. Red changes fastest, then green, then blue.
+	Table [][3]float32
+}
+
+// ParseCubeLUT parses a LUT in the .cube format (Adobe / Resolve)
+func ParseCubeLUT(r io.Reader) (*LUT3D, error) {
+	lut := &LUT3D{DomainMax: [3]float64{1, 1, 1}}
+
+	parseFloats := func(fields []string) ([3]float64, error) {
+		var v [3]float64
+		if len(fields) != 3 {
+			return v, invalidCubeError
+		}
+		for i, field := range fields {
+			f, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return v, invalidCubeError
+			}
+			v[i] = f
+		}
+		return v, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		switch fields[0] {
+		case "TITLE":
+			lut.Title = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "TITLE")), `"`)
+		case "LUT_1D_SIZE", "LUT_1D_INPUT_RANGE":
+			return nil, unsupportedCube1DError
+		case "LUT_3D_SIZE":
+			if len(fields) != 2 {
+				return nil, invalidCubeError
+			}
+			size, err := strconv.Atoi(fields[1])
+			if err != nil || size < 2 {
+				return nil, invalidCubeError
+			}
+			lut.Size = size
+			lut.Table = make([][3]float32, 0, size*size*size)
+		case "DOMAIN_MIN", "DOMAIN_MAX":
+			v, err := parseFloats(fields[1:])
+			if err != nil {
+				return nil, err
+			}
+			if fields[0] == "DOMAIN_MIN" {
+				lut.DomainMin = v
+			} else {
+				lut.DomainMax = v
+			}
+		case "LUT_3D_INPUT_RANGE":
+			if len(fields) != 3 {
+				return nil, invalidCubeError
+			}
+			min, err1 := strconv.ParseFloat(fields[1], 64)
+			max, err2 := strconv.ParseFloat(fields[2], 64)
+			if err1 != nil || err2 != nil {
+				return nil, invalidCubeError
+			}
+			lut.DomainMin = [3]float64{min, min, min}
+			lut.DomainMax = [3]float64{max, max, max}
+		default:
+			if lut.Size == 0 {
+				return nil, missingCubeSizeError
+			}
+			v, err := parseFloats(fields)
+			if err != nil {
+				return nil, err
+			}
+			lut.Table = append(lut.Table, [3]float32{float32(v[0]), float32(v[1]), float32(v[2])})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if lut.Size == 0 {
+		return nil, missingCubeSizeError
+	}
+	if len(lut.Table) != lut.Size*lut.Size*lut.Size {
+		return nil, cubeSizeMismatchError
+	}
+
+	return lut, nil
+}
+
+// LoadCubeLUT loads a .cube file
+func LoadCubeLUT(path string) (*LUT3D, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseCubeLUT(file)
+}
+
+// LUTInterpolation is the way a LUT3D is sampled between its entries
+type LUTInterpolation int
+
+const (
+	// Trilinear interpolates between the 8 entries around the color
+	Trilinear LUTInterpolation = iota
+
+	// Tetrahedral interpolates between the 4 entries of the tetrahedron around the color. Preserves the neutral axis better.
+	Tetrahedral
+)
+
+func (l *LUT3D) entry(r, g, b int) [3]float32 {
+	return l.Table[(b*l.Size+g)*l.Size+r]
+}
+
+// Lookup returns the output color for the input color
+func (l *LUT3D) Lookup(r, g, b float64, interp LUTInterpolation) (float64, float64, float64) {
+	in := [3]float64{r, g, b}
+
+	//position of the color in the table
+	var base [3]int
+	var frac [3]float64
+
+	max := float64(l.Size - 1)
+	for c, v := range in {
+		d := l.DomainMax[c] - l.DomainMin[c]
+		if d <= 0 {
+			d = 1
+		}
+		p := math.Max(math.Min((v-l.DomainMin[c])/d*max, max), 0)
+
+		base[c] = intMin(int(p), l.Size-2)
+		frac[c] = p - float64(base[c])
+	}
+
+	corner := func(dr, dg, db int) [3]float32 {
+		return l.entry(base[0]+dr, base[1]+dg, base[2]+db)
+	}
+
+	var out [3]float64
+
+	if interp == Tetrahedral {
+		fr, fg, fb := frac[0], frac[1], frac[2]
+
+		c000, c111 := corner(0, 0, 0), corner(1, 1, 1)
+
+		//the tetrahedron is chosen by the order of the fractions
+		var a, b [3]float32
+		var w0, w1, w2, w3 float64
+		switch {
+		case fr >= fg && fg >= fb:
+			a, b = corner(1, 0, 0), corner(1, 1, 0)
+			w0, w1, w2, w3 = 1-fr, fr-fg, fg-fb, fb
+		case fr >= fb && fb >= fg:
+			a, b = corner(1, 0, 0), corner(1, 0, 1)
+			w0, w1, w2, w3 = 1-fr, fr-fb, fb-fg, fg
+		case fb >= fr && fr >= fg:
+			a, b = corner(0, 0, 1), corner(1, 0, 1)
+			w0, w1, w2, w3 = 1-fb, fb-fr, fr-fg, fg
+		case fg >= fr && fr >= fb:
+			a, b = corner(0, 1, 0), corner(1, 1, 0)
+			w0, w1, w2, w3 = 1-fg, fg-fr, fr-fb, fb
+		case fg >= fb && fb >= fr:
+			a, b = corner(0, 1, 0), corner(0, 1, 1)
+			w0, w1, w2, w3 = 1-fg, fg-fb, fb-fr, fr
+		default:
+			a, b = corner(0, 0, 1), corner(0, 1, 1)
+			w0, w1, w2, w3 = 1-fb, fb-fg, fg-fr, fr
+		}
+
+		for c := range out {
+			out[c] = w0*float64(c000[c]) + w1*float64(a[c]) + w2*float64(b[c]) + w3*float64(c111[c])
+		}
+		return out[0], out[1], out[2]
+	}
+
+	for db := 0; db < 2; db++ {
+		wb := 1 - frac[2]
+		if db == 1 {
+			wb = frac[2]
+		}
+		for dg := 0; dg < 2; dg++ {
+			wg := 1 - frac[1]
+			if dg == 1 {
+				wg = frac[1]
+			}
+			for dr := 0; dr < 2; dr++ {
+				wr := 1 - frac[0]
+				if dr == 1 {
+					wr = frac[0]
+				}
+
+				w := wr * wg * wb
+				if w == 0 {
+					continue
+				}
+
+				e := corner(dr, dg, db)
+				for c := range out {
+					out[c] += w * float64(e[c])
+				}
+			}
+		}
+	}
+
+	return out[0], out[1], out[2]
+}
+
+// NewLUTTransform creates a FrameTransform which maps the colors of the frame through the LUT.
+// The intensity (0 - 1) mixes the result with the original colors. Empty keyframes apply the LUT fully.
+func NewLUTTransform(lut *LUT3D, interp LUTInterpolation, intensity Keyframes) FrameTransform {
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			mix := math.Max(math.Min(keyframesOr(intensity, f.Time, 1), 1), 0)
+
+			for i := 0; i+4 <= len(f.Data); i += 4 {
+				r, g, b := float64(f.Data[i]), float64(f.Data[i+1]), float64(f.Data[i+2])
+				lr, lg, lb := lut.Lookup(r/255, g/255, b/255, interp)
+
+				f.Data[i] = clampUint8(float32(r + (lr*255-r)*mix))
+				f.Data[i+1] = clampUint8(float32(g + (lg*255-g)*mix))
+				f.Data[i+2] = clampUint8(float32(b + (lb*255-b)*mix))
+			}
+		},
+	}
+}
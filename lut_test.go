@@ -0,0 +1,76 @@
+package gomovie_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+// cubeLUT creates a .cube file of the given size which maps each color through fn
+func cubeLUT(size int, fn func(r, g, b float64) (float64, float64, float64)) string {
+	var sb strings.Builder
+	sb.WriteString("# test lut\nTITLE \"Test\"\n")
+	fmt.Fprintf(&sb, "LUT_3D_SIZE %d\n", size)
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				d := float64(size - 1)
+				or, og, ob := fn(float64(r)/d, float64(g)/d, float64(b)/d)
+				fmt.Fprintf(&sb, "%f %f %f\n", or, og, ob)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func TestParseCubeLUT(t *testing.T) {
+	lut, err := gomovie.ParseCubeLUT(strings.NewReader(cubeLUT(3, func(r, g, b float64) (float64, float64, float64) { return r, g, b })))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lut.Title != "Test" || lut.Size != 3 || len(lut.Table) != 27 {
+		t.Fatalf("Unexpected LUT %v %v %v", lut.Title, lut.Size, len(lut.Table))
+	}
+
+	//red changes fastest
+	if lut.Table[1][0] != .5 || lut.Table[1][1] != 0 {
+		t.Fatalf("Expected red to change fastest but got %v", lut.Table[1])
+	}
+
+	if _, err := gomovie.ParseCubeLUT(strings.NewReader("LUT_3D_SIZE 2\n0 0 0\n")); err == nil {
+		t.Fatalf("Expected an error for a LUT with missing entries")
+	}
+	if _, err := gomovie.ParseCubeLUT(strings.NewReader("LUT_1D_SIZE 2\n0 0 0\n1 1 1\n")); err == nil {
+		t.Fatalf("Expected an error for a 1D LUT")
+	}
+}
+
+func TestLUTTransform(t *testing.T) {
+	invert := func(r, g, b float64) (float64, float64, float64) { return 1 - r, 1 - g, 1 - b }
+	lut, err := gomovie.ParseCubeLUT(strings.NewReader(cubeLUT(5, invert)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, interp := range []gomovie.LUTInterpolation{gomovie.Trilinear, gomovie.Tetrahedral} {
+		//a linear mapping is reproduced exactly between the entries
+		r, g, b := lut.Lookup(.3, .55, .9, interp)
+		if !near(float32(r), .7) || !near(float32(g), .45) || !near(float32(b), .1) {
+			t.Fatalf("Unexpected lookup %v %v %v with interpolation %v", r, g, b, interp)
+		}
+
+		f := pixelFrame(200, 100, 50)
+		gomovie.NewLUTTransform(lut, interp, nil).Transform(f)
+		if f.Data[0] != 55 || f.Data[1] != 155 || f.Data[2] != 205 || f.Data[3] != 255 {
+			t.Fatalf("Expected an inverted pixel but got %v", f.Data)
+		}
+	}
+
+	f := pixelFrame(200, 100, 50)
+	gomovie.NewLUTTransform(lut, gomovie.Tetrahedral, gomovie.Constant(0)).Transform(f)
+	if f.Data[0] != 200 || f.Data[1] != 100 || f.Data[2] != 50 {
+		t.Fatalf("Expected an unchanged pixel at intensity 0 but got %v", f.Data)
+	}
+}
@@ -0,0 +1,242 @@
+package gomovie
+
+import (
+	"io"
+	"math"
+)
+
+// floatFrameReader reads interleaved float frames from a SampleReader regardless of its block size
+type floatFrameReader struct {
+	src      SampleReader
+	channels int
+	buf      []float32
+}
+
+func newFloatFrameReader(src SampleReader) *floatFrameReader {
+	return &floatFrameReader{src: src, channels: src.Info().Channels}
+}
+
+// read returns up to n frames. Returns io.EOF when there are no frames left.
+func (r *floatFrameReader) read(n int) ([]float32, error) {
+	for len(r.buf) < n*r.channels {
+		b, err := r.src.ReadSampleBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.buf = append(r.buf, b.Floats()...)
+	}
+
+	if len(r.buf) == 0 {
+		return nil, io.EOF
+	}
+
+	l := intMin(n*r.channels, len(r.buf))
+	out := r.buf[:l:l]
+	r.buf = r.buf[l:]
+
+	return out, nil
+}
+
+// MixerTrack describes a SampleReader in an AudioMixer
+type MixerTrack struct {
+	Reader SampleReader
+
+	// Gain is a linear gain. 1 leaves the level unchanged.
+	Gain float64
+
+	// Pan from -1 (left) to 1 (right). Only used for stereo output. The opposite channel is lowered.
+	Pan float64
+
+	// Start is the time in seconds in the mix at which the track starts
+	Start float32
+
+	Mute bool
+	Solo bool
+}
+
+// NewMixerTrack creates a MixerTrack with unity gain which starts at the beginning of the mix
+func NewMixerTrack(reader SampleReader) *MixerTrack {
+	return &MixerTrack{Reader: reader, Gain: 1}
+}
+
+func (t *MixerTrack) channelGains(channels int) []float32 {
+	gains := make([]float32, channels)
+	for c := range gains {
+		gains[c] = float32(t.Gain)
+	}
+
+	if channels == 2 {
+		gains[0] *= float32(math.Min(1, 1-t.Pan))
+		gains[1] *= float32(math.Min(1, 1+t.Pan))
+	}
+
+	return gains
+}
+
+// AudioMixer sums the tracks into a single SampleReader. Tracks with a different sample rate or channel count are converted.
+// implements the SampleReader interface.
+type AudioMixer struct {
+	floatSampleBase
+
+	Tracks []*MixerTrack
+
+	// Limit applies a soft limiter to the mix to prevent clipping. Enabled by NewAudioMixer.
+	Limit bool
+
+	r *Range
+
+	inputs map[*MixerTrack]*floatFrameReader
+}
+
+// NewAudioMixer creates an AudioMixer for the tracks. The output uses the highest sample rate and channel count of the tracks.
+// The duration is the end of the last track. The info can be changed before reading.
+func NewAudioMixer(tracks ...*MixerTrack) *AudioMixer {
+	m := &AudioMixer{Limit: true, floatSampleBase: floatSampleBase{i: new(SampleReaderInfo), o: NewSampleFormat()}}
+	for _, t := range tracks {
+		m.AddTrack(t)
+	}
+	return m
+}
+
+// AddTrack adds the track and updates the info of the mixer
+func (m *AudioMixer) AddTrack(t *MixerTrack) *AudioMixer {
+	m.Tracks = append(m.Tracks, t)
+
+	info := t.Reader.Info()
+	m.i.SampleRate = intMax(m.i.SampleRate, info.SampleRate)
+	m.i.Channels = intMax(m.i.Channels, info.Channels)
+	m.i.Duration = float32Max(m.i.Duration, t.Start+sampleReaderDuration(t.Reader))
+
+	return m
+}
+
+func (m *AudioMixer) Range() *Range { return m.r }
+
+// Close closes the readers of all the tracks
+func (m *AudioMixer) Close() (err error) {
+	for _, t := range m.Tracks {
+		if err = t.Reader.Close(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Slice returns a new AudioMixer for the range. The tracks are sliced and moved so they keep their position in the mix.
+func (m *AudioMixer) Slice(r *Range) SampleReader {
+	r = r.Intersection(&Range{Start: 0, Duration: sampleReaderDuration(m)})
+	r.parent = m.r
+
+	o := *m.o
+	s := &AudioMixer{Limit: m.Limit, floatSampleBase: floatSampleBase{i: m.i, o: &o}, r: r}
+
+	for _, t := range m.Tracks {
+		tc := *t
+
+		//every slice gets its own readers
+		if r.Start > t.Start {
+			tc.Reader = t.Reader.Slice(&Range{Start: r.Start - t.Start, Duration: r.Duration})
+			tc.Start = 0
+		} else {
+			tc.Reader = t.Reader.Slice(&Range{Start: 0, Duration: sampleReaderDuration(t.Reader)})
+			tc.Start = t.Start - r.Start
+		}
+
+		s.Tracks = append(s.Tracks, &tc)
+	}
+
+	return s
+}
+
+// activeTracks returns the tracks which are not muted and soloed when any track is soloed
+func (m *AudioMixer) activeTracks() map[*MixerTrack]bool {
+	solo := false
+	for _, t := range m.Tracks {
+		solo = solo || t.Solo
+	}
+
+	tracks := make(map[*MixerTrack]bool)
+	for _, t := range m.Tracks {
+		if !t.Mute && (!solo || t.Solo) {
+			tracks[t] = true
+		}
+	}
+	return tracks
+}
+
+// ReadSampleBlock mixes the next block of all the active tracks
+func (m *AudioMixer) ReadSampleBlock() (*SampleBlock, error) {
+	rate, channels := m.i.SampleRate, m.i.Channels
+
+	if m.inputs == nil {
+		m.inputs = make(map[*MixerTrack]*floatFrameReader)
+		for _, t := range m.Tracks {
+			m.inputs[t] = newFloatFrameReader(convertSampleReader(t.Reader, rate, channels))
+		}
+	}
+
+	total := int(math.Floor(float64(sampleReaderDuration(m))*float64(rate) + 1e-3))
+
+	frames := intMin(m.blockFrames(), total-m.offset)
+	if frames <= 0 {
+		return nil, io.EOF
+	}
+
+	mix := make([]float32, frames*channels)
+
+	active := m.activeTracks()
+
+	//the inactive tracks are read as well so they stay in sync when they become active
+	for _, t := range m.Tracks {
+		//index in the block where the track starts
+		from := intMax(int(math.Floor(float64(t.Start)*float64(rate)+.5))-m.offset, 0)
+		if from >= frames {
+			continue
+		}
+
+		data, err := m.inputs[t].read(frames - from)
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !active[t] {
+			continue
+		}
+
+		gains := t.channelGains(channels)
+		for i, v := range data {
+			mix[from*channels+i] += v * gains[i%channels]
+		}
+	}
+
+	if m.Limit {
+		for i, v := range mix {
+			mix[i] = softLimit(v)
+		}
+	}
+
+	return m.block(mix), nil
+}
+
+func (m *AudioMixer) Read(p []byte) (int, error) {
+	return m.read(p, m.ReadSampleBlock)
+}
+
+// softLimit leaves values below the knee untouched and smoothly compresses everything above it so it never exceeds 1
+func softLimit(v float32) float32 {
+	const knee = .9
+
+	a := math.Abs(float64(v))
+	if a <= knee {
+		return v
+	}
+
+	l := knee + (1-knee)*math.Tanh((a-knee)/(1-knee))
+	return float32(math.Copysign(l, float64(v)))
+}
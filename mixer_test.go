@@ -0,0 +1,113 @@
+package gomovie_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestAudioMixer(t *testing.T) {
+	music := gomovie.NewToneReader(gomovie.Sine, 440, .5, &gomovie.SampleReaderInfo{SampleRate: 48000, Channels: 2, Duration: 2})
+	voice := gomovie.NewToneReader(gomovie.Square, 1000, .25, &gomovie.SampleReaderInfo{SampleRate: 44100, Channels: 1, Duration: 1})
+
+	voiceTrack := gomovie.NewMixerTrack(voice)
+	voiceTrack.Start = 1.5
+	voiceTrack.Pan = -1
+
+	mixer := gomovie.NewAudioMixer(gomovie.NewMixerTrack(music), voiceTrack)
+
+	info := mixer.Info()
+	if info.SampleRate != 48000 || info.Channels != 2 || info.Duration != 2.5 {
+		t.Fatalf("Unexpected info %+v", info)
+	}
+
+	samples := readFloats(t, mixer)
+	if len(samples) != 2*120000 {
+		t.Fatalf("Expected %v samples but got %v", 2*120000, len(samples))
+	}
+
+	//after the music only the voice should remain on the left channel
+	var left, right float64
+	for i := 2 * 100000; i < len(samples); i += 2 {
+		left = math.Max(left, math.Abs(float64(samples[i])))
+		right = math.Max(right, math.Abs(float64(samples[i+1])))
+	}
+
+	//the voice is resampled from 44.1 kHz so the edges of the square ring (see TestResamplerRinging)
+	if left < .25 || left > .25*1.35 || right != 0 {
+		t.Fatalf("Expected the voice only on the left channel but got %v %v", left, right)
+	}
+}
+
+func TestAudioMixerSolo(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1}
+
+	a := gomovie.NewMixerTrack(gomovie.NewToneReader(gomovie.Sine, 100, 1, info))
+	b := gomovie.NewMixerTrack(gomovie.NewToneReader(gomovie.Sine, 100, 1, info))
+	b.Solo = true
+	b.Gain = .5
+
+	for _, s := range readFloats(t, gomovie.NewAudioMixer(a, b)) {
+		if math.Abs(float64(s)) > .51 {
+			t.Fatal("Only the solo track should be audible")
+		}
+	}
+}
+
+func TestAudioMixerMuteSync(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1}
+	tone := readFloats(t, gomovie.NewToneReader(gomovie.Sine, 100, .5, info))
+
+	track := gomovie.NewMixerTrack(gomovie.NewToneReader(gomovie.Sine, 100, .5, info))
+	track.Mute = true
+
+	mixer := gomovie.NewAudioMixer(track)
+
+	muted, err := mixer.ReadSampleBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the muted samples are skipped so the track continues where it would have been
+	track.Mute = false
+
+	block, err := mixer.ReadSampleBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offset := len(muted.Floats())
+	for i, v := range block.Floats() {
+		if math.Abs(float64(v-tone[offset+i])) > 1e-3 {
+			t.Fatalf("Expected %v at %v but got %v", tone[offset+i], offset+i, v)
+		}
+	}
+}
+
+func TestAudioMixerSlice(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1}
+
+	track := gomovie.NewMixerTrack(gomovie.NewToneReader(gomovie.Sine, 100, 1, info))
+	track.Start = .5
+
+	mixer := gomovie.NewAudioMixer(track)
+
+	//both slices start before the track so each needs its own reader of the track
+	first := readFloats(t, mixer.Slice(&gomovie.Range{Start: .25, Duration: 1}))
+	second := readFloats(t, mixer.Slice(&gomovie.Range{Start: .25, Duration: 1}))
+
+	if len(first) != 8000 || len(second) != len(first) {
+		t.Fatalf("Expected 8000 samples but got %v and %v", len(first), len(second))
+	}
+
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected the same samples for both slices but got %v and %v at %v", first[i], second[i], i)
+		}
+	}
+
+	if first[4000] == 0 && first[4020] == 0 {
+		t.Fatal("Expected the track after .25 seconds")
+	}
+}
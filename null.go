@@ -45,12 +45,9 @@ func (src *nullFrameReader) lastFrame() (n int) {
 	return
 }
 
+// frameIndexToTime returns the time relative to the start of the reader like the other readers
 func (src *nullFrameReader) frameIndexToTime(index int) float32 {
-	var s float32
-	if src.r != nil {
-		s = src.r.Start
-	}
-	return s + float32(index)*float32(1./src.i.FrameRate)
+	return float32(index) * float32(1./src.i.FrameRate)
 }
 
 func (src *nullFrameReader) ReadFrame() (*Frame, error) {
@@ -63,7 +60,7 @@ func (src *nullFrameReader) ReadFrame() (*Frame, error) {
 	}
 
 	fr := &Frame{
-		Data:   src.buf[:],
+		Data:   make([]byte, len(src.buf)),
 		Index:  src.frameIndex,
 		Time:   src.frameIndexToTime(src.frameIndex),
 		Width:  src.i.Width,
@@ -116,17 +113,14 @@ type nullSampleReader struct {
 }
 
 func (src *nullSampleReader) init() {
-	bytesPerSample := src.o.Depth / 8
+	bytesPerSample := src.o.BytesPerSample()
 
-	src.totalBytes = int(src.i.Duration * float32(bytesPerSample*src.i.Channels*src.i.SampleRate))
+	frames := int(math.Floor(float64(sampleReaderDuration(src))*float64(src.i.SampleRate) + 1e-3))
+	src.totalBytes = frames * bytesPerSample * src.i.Channels
 
-	//only used for ReadSampleBlock
-	switch src.o.Depth {
-	case 16:
-		src.sampleData = make([]SampleInt16, src.o.BlockSize/bytesPerSample)
-	case 32:
-		src.sampleData = make([]SampleInt32, src.o.BlockSize/bytesPerSample)
-	}
+	//only used for ReadSampleBlock. Whole frames so a block never splits the channels
+	frameSize := bytesPerSample * src.i.Channels
+	src.sampleData = makeSampleData(src.o, src.o.BlockSize/frameSize*src.i.Channels)
 
 	//only used for Read
 	src.buf = make([]byte, src.totalBytes) //all zero values
@@ -144,7 +138,8 @@ func (src *nullSampleReader) Slice(r *Range) SampleReader {
 		r = r.Intersection(src.r)
 		r.parent = src.r
 	}
-	return &nullSampleReader{i: src.i, r: r}
+	o := *src.o
+	return &nullSampleReader{i: src.i, o: &o, r: r}
 }
 
 func (src *nullSampleReader) Read(p []byte) (n int, err error) {
@@ -173,41 +168,37 @@ func (src *nullSampleReader) ReadSampleBlock() (*SampleBlock, error) {
 		src.init()
 	}
 
-	//get bytes for total duraton
-	bytesPerSample := src.o.Depth / 8
+	frameSize := src.o.BytesPerSample() * src.i.Channels
 
 	//we should not read beyond allowed range
 	sd := src.sampleData
-	br := src.o.BlockSize // normal range
-	if src.offset+br >= src.totalBytes {
-		br = src.totalBytes - br
-
-		switch src.o.Depth {
-		case 16:
-			sd = sd.([]SampleInt16)[:(br / bytesPerSample)]
-		case 32:
-			sd = sd.([]SampleInt32)[:(br / bytesPerSample)]
-		}
+	n := (&SampleBlock{Data: sd}).Len()
+	if remaining := (src.totalBytes - src.offset) / frameSize * src.i.Channels; remaining < n {
+		n = remaining
+		sd = makeSampleData(src.o, n)
 	}
 
-	if br == 0 {
+	if n <= 0 {
 		return nil, io.EOF
 	}
 
 	//convert the byte shizzle to actual time values
-	a := float32(bytesPerSample*src.i.SampleRate) / float32(src.i.Channels)
+	a := float32(frameSize * src.i.SampleRate)
 	time := float32(src.offset) / a
+	br := n / src.i.Channels * frameSize
 	duration := float32(br) / a
 
 	src.offset += br
 
 	return &SampleBlock{
-		Data:     sd,
-		Time:     time,
-		Duration: duration,
+		SampleFormat: src.o,
+		Data:         sd,
+		Time:         time,
+		Duration:     duration,
+		Channels:     src.i.Channels,
 	}, nil
 }
 
 func NewNullSampleReader(info *SampleReaderInfo) SampleReader {
-	return &nullSampleReader{i: info}
+	return &nullSampleReader{i: info, o: NewSampleFormat()}
 }
@@ -0,0 +1,21 @@
+package gomovie_test
+
+import (
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestNullFrameReaderSliceTime(t *testing.T) {
+	reader := gomovie.NewNullFrameReader(&gomovie.FrameReaderInfo{Width: 4, Height: 4, FrameRate: 25, Duration: 4})
+
+	frames := readFrames(t, reader.Slice(&gomovie.Range{Start: 1, Duration: 1}))
+	if len(frames) == 0 {
+		t.Fatal("Expected frames in the slice")
+	}
+
+	//the time is relative to the start of the slice
+	if frames[0].Time != 0 || !near(frames[1].Time, .04) {
+		t.Fatalf("Expected the slice to start at 0 but got %v %v", frames[0].Time, frames[1].Time)
+	}
+}
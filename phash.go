@@ -0,0 +1,265 @@
+package gomovie
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+var (
+	mismatchedFingerprintError  = errors.New("Fingerprints use a different rate or algorithm")
+	invalidFingerprintRateError = errors.New("Fingerprint rate should be above 0")
+)
+
+// HashAlgorithm describes how a FrameHash is calculated
+type HashAlgorithm int
+
+const (
+	// AverageHash compares each pixel of an 8x8 thumbnail with the mean. Fast but sensitive to contrast changes.
+	AverageHash HashAlgorithm = iota
+	// DifferenceHash compares each pixel of a 9x8 thumbnail with its right neighbour
+	DifferenceHash
+	// PerceptualHash compares the low frequencies of the DCT of a 32x32 thumbnail with their median. The most robust.
+	PerceptualHash
+)
+
+// FrameHash is a 64 bit perceptual hash. Similar frames have hashes with a small hamming distance.
+type FrameHash uint64
+
+// Distance returns the number of bits (0 - 64) which differ
+func (h FrameHash) Distance(o FrameHash) int {
+	return bits.OnesCount64(uint64(h ^ o))
+}
+
+func (h FrameHash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// grayThumbnail returns the luma of the frame scaled to w x h
+func grayThumbnail(f *Frame, w, h int) []float64 {
+	return lumaPlane(resizeRGBA(f.Data, f.Width, f.Height, w, h, Bilinear))
+}
+
+// hashBits sets a bit for each value which is true for the comparison
+func hashBits(values []float64, set func(i int, v float64) bool) (h FrameHash) {
+	for i, v := range values {
+		if set(i, v) {
+			h |= 1 << uint(i)
+		}
+	}
+	return
+}
+
+// HashFrame returns the perceptual hash of the frame with the algorithm
+func HashFrame(f *Frame, algorithm HashAlgorithm) FrameHash {
+	switch algorithm {
+	case DifferenceHash:
+		g := grayThumbnail(f, 9, 8)
+
+		var h FrameHash
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				if g[y*9+x] < g[y*9+x+1] {
+					h |= 1 << uint(y*8+x)
+				}
+			}
+		}
+		return h
+	case PerceptualHash:
+		low := dctLowFrequencies(grayThumbnail(f, 32, 32), 32, 8)
+
+		//the median without the DC which is much larger than the rest
+		sorted := append([]float64(nil), low[1:]...)
+		sort.Float64s(sorted)
+		median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+		return hashBits(low, func(i int, v float64) bool { return v > median })
+	}
+
+	g := grayThumbnail(f, 8, 8)
+
+	var mean float64
+	for _, v := range g {
+		mean += v
+	}
+	mean /= float64(len(g))
+
+	return hashBits(g, func(i int, v float64) bool { return v > mean })
+}
+
+// dctLowFrequencies returns the n x n lowest frequencies of the 2D DCT-II of a size x size plane
+func dctLowFrequencies(plane []float64, size, n int) []float64 {
+	cos := make([]float64, n*size)
+	for u := 0; u < n; u++ {
+		for x := 0; x < size; x++ {
+			cos[u*size+x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / float64(2*size))
+		}
+	}
+
+	//rows first
+	rows := make([]float64, size*n)
+	for y := 0; y < size; y++ {
+		for u := 0; u < n; u++ {
+			var v float64
+			for x := 0; x < size; x++ {
+				v += plane[y*size+x] * cos[u*size+x]
+			}
+			rows[y*n+u] = v
+		}
+	}
+
+	out := make([]float64, n*n)
+	for v := 0; v < n; v++ {
+		for u := 0; u < n; u++ {
+			var s float64
+			for y := 0; y < size; y++ {
+				s += rows[y*n+u] * cos[v*size+y]
+			}
+			out[v*n+u] = s
+		}
+	}
+
+	return out
+}
+
+// Fingerprint is a sequence of frame hashes sampled at a fixed rate
+type Fingerprint struct {
+	// Rate in hashes per second
+	Rate      float32
+	Algorithm HashAlgorithm
+	Hashes    []FrameHash
+}
+
+// NewFingerprint reads the frames and hashes the frame shown at every 1 / rate seconds
+func NewFingerprint(reader FrameReader, rate float32, algorithm HashAlgorithm) (*Fingerprint, error) {
+	if rate <= 0 {
+		return nil, invalidFingerprintRateError
+	}
+
+	fp := &Fingerprint{Rate: rate, Algorithm: algorithm}
+	half := .5 / reader.Info().FrameRate
+
+	//time of the next sample
+	next := func() float32 { return float32(len(fp.Hashes)) / rate }
+
+	for {
+		f, err := reader.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if next() > f.Time+half {
+			continue
+		}
+
+		h := HashFrame(f, algorithm)
+		for next() <= f.Time+half {
+			fp.Hashes = append(fp.Hashes, h)
+		}
+	}
+
+	return fp, nil
+}
+
+// Duration returns the duration covered by the hashes
+func (fp *Fingerprint) Duration() float32 {
+	return float32(len(fp.Hashes)) / fp.Rate
+}
+
+// distance returns the mean hamming distance of the clip placed at hash offset
+func (fp *Fingerprint) distance(clip *Fingerprint, offset int) float64 {
+	var sum int
+	for i, h := range clip.Hashes {
+		sum += h.Distance(fp.Hashes[offset+i])
+	}
+	return float64(sum) / float64(len(clip.Hashes))
+}
+
+// FingerprintMatch is a place where a clip was found
+type FingerprintMatch struct {
+	// Offset in seconds where the clip starts
+	Offset float32
+
+	// Distance is the mean hamming distance (0 - 64) of the hashes
+	Distance float64
+
+	// Similarity between 0 and 1. 1 is identical.
+	Similarity float64
+}
+
+// Find returns the places where the clip occurs with a mean hamming distance of at most maxDistance, best match first.
+// Overlapping matches are reduced to the best one. Both fingerprints should have the same rate and algorithm.
+func (fp *Fingerprint) Find(clip *Fingerprint, maxDistance float64) ([]FingerprintMatch, error) {
+	if fp.Rate != clip.Rate || fp.Algorithm != clip.Algorithm {
+		return nil, mismatchedFingerprintError
+	}
+
+	n := len(clip.Hashes)
+	if n == 0 || n > len(fp.Hashes) {
+		return nil, nil
+	}
+
+	type candidate struct {
+		offset   int
+		distance float64
+	}
+
+	var candidates []candidate
+	for o := 0; o+n <= len(fp.Hashes); o++ {
+		if d := fp.distance(clip, o); d <= maxDistance {
+			candidates = append(candidates, candidate{o, d})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+
+	var matches []FingerprintMatch
+	var taken []int
+
+	for _, c := range candidates {
+		overlaps := false
+		for _, o := range taken {
+			if c.offset < o+n && o < c.offset+n {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+
+		taken = append(taken, c.offset)
+		matches = append(matches, FingerprintMatch{
+			Offset:     float32(c.offset) / fp.Rate,
+			Distance:   c.distance,
+			Similarity: 1 - c.distance/64,
+		})
+	}
+
+	return matches, nil
+}
+
+// Similarity compares two fingerprints from the start over the length of the shortest. Returns a value between 0 and 1.
+// Use it to find duplicates. Uploads of the same video usually have a similarity above .9.
+func (fp *Fingerprint) Similarity(o *Fingerprint) (float64, error) {
+	if fp.Rate != o.Rate || fp.Algorithm != o.Algorithm {
+		return 0, mismatchedFingerprintError
+	}
+
+	short, long := fp, o
+	if len(o.Hashes) < len(fp.Hashes) {
+		short, long = o, fp
+	}
+
+	if len(short.Hashes) == 0 {
+		return 0, nil
+	}
+
+	return 1 - long.distance(short, 0)/64, nil
+}
@@ -0,0 +1,104 @@
+package gomovie_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func firstFrame(t *testing.T, reader gomovie.FrameReader) *gomovie.Frame {
+	f, err := reader.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestHashFrame(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 128, Height: 72, FrameRate: 25, Duration: 1}
+	small := &gomovie.FrameReaderInfo{Width: 64, Height: 36, FrameRate: 25, Duration: 1}
+
+	bars := firstFrame(t, gomovie.NewColorBarsClip(info))
+	smallBars := firstFrame(t, gomovie.NewColorBarsClip(small))
+	gradient := firstFrame(t, gomovie.NewGradientClip(color.Black, color.White, 0, info))
+
+	for _, algorithm := range []gomovie.HashAlgorithm{gomovie.AverageHash, gomovie.DifferenceHash, gomovie.PerceptualHash} {
+		h := gomovie.HashFrame(bars, algorithm)
+
+		if d := h.Distance(gomovie.HashFrame(smallBars, algorithm)); d > 4 {
+			t.Fatalf("Expected a small distance for a resized frame with algorithm %v but got %v", algorithm, d)
+		}
+
+		if d := h.Distance(gomovie.HashFrame(gradient, algorithm)); d < 16 {
+			t.Fatalf("Expected a large distance for a different frame with algorithm %v but got %v", algorithm, d)
+		}
+	}
+}
+
+func TestFingerprintFind(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 128, Height: 72, FrameRate: 25, Duration: 1}
+	small := &gomovie.FrameReaderInfo{Width: 64, Height: 36, FrameRate: 30, Duration: 1}
+
+	library := gomovie.Concat(
+		gomovie.NewColorBarsClip(info),
+		gomovie.NewGradientClip(color.Black, color.White, 90, info),
+		gomovie.NewCheckerboardClip(8, color.Black, color.White, info),
+		gomovie.NewGradientClip(color.White, color.Black, 0, info),
+	).FrameReader
+
+	//the reused footage at a different size and frame rate
+	clip := gomovie.Concat(
+		gomovie.NewGradientClip(color.Black, color.White, 90, small),
+		gomovie.NewCheckerboardClip(4, color.Black, color.White, small),
+	).FrameReader
+
+	a, err := gomovie.NewFingerprint(library, 4, gomovie.PerceptualHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := gomovie.NewFingerprint(clip, 4, gomovie.PerceptualHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.Hashes) != 16 || len(b.Hashes) != 8 {
+		t.Fatalf("Unexpected number of hashes %v and %v", len(a.Hashes), len(b.Hashes))
+	}
+
+	matches, err := a.Find(b, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matches) != 1 || matches[0].Offset != 1 {
+		t.Fatalf("Expected a match at 1 but got %+v", matches)
+	}
+}
+
+func TestFingerprintSimilarity(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 64, Height: 36, FrameRate: 25, Duration: 2}
+
+	a, _ := gomovie.NewFingerprint(gomovie.NewColorBarsClip(info), 2, gomovie.DifferenceHash)
+	b, _ := gomovie.NewFingerprint(gomovie.NewColorBarsClip(info), 2, gomovie.DifferenceHash)
+
+	if s, err := a.Similarity(b); err != nil || s != 1 {
+		t.Fatalf("Expected identical fingerprints but got %v %v", s, err)
+	}
+
+	c, _ := gomovie.NewFingerprint(gomovie.NewColorBarsClip(info), 2, gomovie.AverageHash)
+	if _, err := a.Similarity(c); err == nil {
+		t.Fatal("Expected an error for different algorithms")
+	}
+}
+
+func TestFingerprintInvalidRate(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 32, Height: 32, FrameRate: 25, Duration: 1}
+
+	for _, rate := range []float32{0, -1} {
+		if _, err := gomovie.NewFingerprint(gomovie.NewColorBarsClip(info), rate, gomovie.DifferenceHash); err == nil {
+			t.Fatalf("Expected an error for rate %v", rate)
+		}
+	}
+}
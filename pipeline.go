@@ -0,0 +1,316 @@
+package gomovie
+
+import (
+	"container/heap"
+	"io"
+	"sync"
+)
+
+// Stage is a step of a Pipeline. Process returns the item for the next stage.
+type Stage struct {
+	Process func(item interface{}) (interface{}, error)
+
+	// Parallel is the number of workers. A stage with a single worker (or 0) gets the items in order so it can keep state.
+	Parallel int
+
+	// Before and After turn the stage into a window stage. Process gets a *StageWindow with up to Before previous and
+	// After next items around each item instead of the item itself.
+	Before, After int
+}
+
+// StageWindow is passed to the Process of a window stage
+type StageWindow struct {
+	// Items in the order of the source. Shorter at the start and the end of the source.
+	Items []interface{}
+
+	// Current is the position in Items of the item which is processed
+	Current int
+}
+
+// pipelineItem is an item with its position in the source
+type pipelineItem struct {
+	index int
+	value interface{}
+}
+
+// pipelineHeap is the reorder buffer. The item with the lowest index is on top.
+type pipelineHeap []pipelineItem
+
+func (h pipelineHeap) Len() int            { return len(h) }
+func (h pipelineHeap) Less(i, j int) bool  { return h[i].index < h[j].index }
+func (h pipelineHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *pipelineHeap) Push(x interface{}) { *h = append(*h, x.(pipelineItem)) }
+func (h *pipelineHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// reorder collects items until the item with the next index is available
+type reorder struct {
+	h    pipelineHeap
+	next int
+}
+
+func (r *reorder) push(item pipelineItem) {
+	heap.Push(&r.h, item)
+}
+
+// pop returns the item with the next index when it is available
+func (r *reorder) pop() (pipelineItem, bool) {
+	if len(r.h) == 0 || r.h[0].index != r.next {
+		return pipelineItem{}, false
+	}
+	r.next++
+	return heap.Pop(&r.h).(pipelineItem), true
+}
+
+// Pipeline reads items from a source and passes them through stages which run concurrently.
+// Next returns the items in the order of the source. At most buffer items are in flight so a slow consumer
+// stops the source (backpressure) and the reorder buffers never grow beyond buffer items.
+// The first error of the source or any stage stops the pipeline and is returned by Next.
+type Pipeline struct {
+	source func() (interface{}, error)
+	stages []Stage
+	buffer int
+
+	started bool
+	out     chan pipelineItem
+	slots   chan struct{}
+	quit    chan struct{}
+	read    chan struct{} //closed when the source is no longer read
+	order   reorder
+
+	errOnce sync.Once
+	err     error
+	closed  sync.Once
+}
+
+// NewPipeline creates a pipeline which reads the source until it returns io.EOF. The buffer is the maximum number of items in flight.
+// The goroutines are started by the first call to Next.
+// The buffer is raised when the window stages need more items ahead.
+func NewPipeline(source func() (interface{}, error), buffer int, stages ...Stage) *Pipeline {
+	ahead := 0
+	for _, s := range stages {
+		ahead += s.After
+	}
+	buffer = intMax(buffer, ahead+1)
+
+	return &Pipeline{source: source, stages: stages, buffer: buffer}
+}
+
+func (p *Pipeline) fail(err error) {
+	p.errOnce.Do(func() {
+		p.err = err
+		close(p.quit)
+	})
+}
+
+func (p *Pipeline) start() {
+	p.started = true
+	p.slots = make(chan struct{}, p.buffer)
+	p.quit = make(chan struct{})
+	p.read = make(chan struct{})
+
+	source := make(chan pipelineItem)
+
+	go func() {
+		defer close(p.read)
+		defer close(source)
+
+		for i := 0; ; i++ {
+			//wait until there is room
+			select {
+			case p.slots <- struct{}{}:
+			case <-p.quit:
+				return
+			}
+
+			v, err := p.source()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				p.fail(err)
+				return
+			}
+
+			select {
+			case source <- pipelineItem{i, v}:
+			case <-p.quit:
+				return
+			}
+		}
+	}()
+
+	out := source
+	for _, s := range p.stages {
+		if s.Before > 0 || s.After > 0 {
+			out = p.window(s, out)
+		}
+		out = p.runStage(s, out)
+	}
+
+	p.out = out
+}
+
+// window puts the items in order and replaces each item by a *StageWindow once the items after it have arrived
+func (p *Pipeline) window(s Stage, in chan pipelineItem) chan pipelineItem {
+	out := make(chan pipelineItem)
+
+	go func() {
+		defer close(out)
+
+		var (
+			order reorder
+			items []interface{}
+			first int //index of items[0]
+			next  int //index of the next item to send
+		)
+
+		send := func() bool {
+			current := next - first
+			w := &StageWindow{Items: items[:intMin(current+s.After+1, len(items))], Current: current}
+
+			select {
+			case out <- pipelineItem{next, w}:
+			case <-p.quit:
+				return false
+			}
+
+			next++
+
+			//forget the items which are no longer in any window
+			if drop := next - s.Before - first; drop > 0 {
+				items = append(items[:0:0], items[drop:]...)
+				first += drop
+			}
+			return true
+		}
+
+		for item := range in {
+			order.push(item)
+			for i, ok := order.pop(); ok; i, ok = order.pop() {
+				items = append(items, i.value)
+				if first+len(items)-1 >= next+s.After && !send() {
+					return
+				}
+			}
+		}
+
+		//the last items have a shorter window
+		for next < first+len(items) {
+			if !send() {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// runStage starts the workers of the stage and returns the channel with the processed items
+func (p *Pipeline) runStage(s Stage, in chan pipelineItem) chan pipelineItem {
+	out := make(chan pipelineItem)
+
+	send := func(item pipelineItem) bool {
+		v, err := s.Process(item.value)
+		if err != nil {
+			p.fail(err)
+			return false
+		}
+
+		select {
+		case out <- pipelineItem{item.index, v}:
+			return true
+		case <-p.quit:
+			return false
+		}
+	}
+
+	if s.Parallel <= 1 {
+		go func() {
+			defer close(out)
+
+			//the previous stages might be parallel so the items are put back in order
+			var order reorder
+			for item := range in {
+				order.push(item)
+				for next, ok := order.pop(); ok; next, ok = order.pop() {
+					if !send(next) {
+						return
+					}
+				}
+			}
+		}()
+		return out
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(s.Parallel)
+
+	for i := 0; i < s.Parallel; i++ {
+		go func() {
+			defer wg.Done()
+			for item := range in {
+				if !send(item) {
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// Next returns the next item in the order of the source. Returns io.EOF after the last item or the first error of the pipeline.
+func (p *Pipeline) Next() (interface{}, error) {
+	if !p.started {
+		p.start()
+	}
+
+	for {
+		if item, ok := p.order.pop(); ok {
+			<-p.slots
+			return item.value, nil
+		}
+
+		select {
+		case item, ok := <-p.out:
+			if !ok {
+				//stopped by an error or all the items were read
+				select {
+				case <-p.quit:
+					return nil, p.err
+				default:
+					return nil, io.EOF
+				}
+			}
+			p.order.push(item)
+		case <-p.quit:
+			return nil, p.err
+		}
+	}
+}
+
+// Close stops the goroutines of the pipeline. Next returns io.ErrClosedPipe afterwards.
+// It waits until the source is no longer read so the source can be closed afterwards.
+func (p *Pipeline) Close() {
+	p.closed.Do(func() {
+		if !p.started {
+			//never started so Next only sees the error
+			p.started = true
+			p.quit = make(chan struct{})
+			p.fail(io.ErrClosedPipe)
+			return
+		}
+		p.fail(io.ErrClosedPipe)
+		<-p.read
+	})
+}
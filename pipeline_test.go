@@ -0,0 +1,158 @@
+package gomovie_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func counter(n int, read *int32) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		i := int(atomic.AddInt32(read, 1)) - 1
+		if i >= n {
+			return nil, io.EOF
+		}
+		return i, nil
+	}
+}
+
+func TestPipelineOrder(t *testing.T) {
+	var read int32
+	var last = -2
+
+	slow := gomovie.Stage{
+		Parallel: 8,
+		Process: func(item interface{}) (interface{}, error) {
+			time.Sleep(time.Duration(rand.Intn(200)) * time.Microsecond)
+			return item.(int) * 2, nil
+		},
+	}
+
+	//a sequential stage gets the items in order after the parallel stage
+	ordered := gomovie.Stage{
+		Process: func(item interface{}) (interface{}, error) {
+			if item.(int) != last+2 {
+				return nil, errors.New("Out of order")
+			}
+			last = item.(int)
+			return item, nil
+		},
+	}
+
+	p := gomovie.NewPipeline(counter(500, &read), 16, slow, ordered)
+
+	for i := 0; i < 500; i++ {
+		v, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.(int) != i*2 {
+			t.Fatalf("Expected %v but got %v", i*2, v)
+		}
+	}
+
+	if _, err := p.Next(); err != io.EOF {
+		t.Fatalf("Expected EOF but got %v", err)
+	}
+}
+
+func TestPipelineBackpressure(t *testing.T) {
+	var read int32
+	p := gomovie.NewPipeline(counter(100, &read), 4, gomovie.Stage{
+		Parallel: 2,
+		Process:  func(item interface{}) (interface{}, error) { return item, nil },
+	})
+	defer p.Close()
+
+	p.Next()
+	time.Sleep(10 * time.Millisecond)
+
+	//1 item returned and at most 4 in flight
+	if n := atomic.LoadInt32(&read); n > 5 {
+		t.Fatalf("Expected the source to wait but it read %v items", n)
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	var read int32
+	failure := errors.New("Failed")
+
+	p := gomovie.NewPipeline(counter(100, &read), 8, gomovie.Stage{
+		Parallel: 4,
+		Process: func(item interface{}) (interface{}, error) {
+			if item.(int) == 10 {
+				return nil, failure
+			}
+			return item, nil
+		},
+	})
+
+	for {
+		_, err := p.Next()
+		if err == failure {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected the error of the stage but got %v", err)
+		}
+	}
+
+	if _, err := p.Next(); err != failure {
+		t.Fatalf("Expected the error to stay but got %v", err)
+	}
+}
+
+func TestPipelineClose(t *testing.T) {
+	var read int32
+	p := gomovie.NewPipeline(counter(100, &read), 4)
+
+	p.Next()
+	p.Close()
+
+	if _, err := p.Next(); err != io.ErrClosedPipe {
+		t.Fatalf("Expected ErrClosedPipe but got %v", err)
+	}
+}
+
+func TestFrameTransformerReadOrder(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 16, Height: 8, FrameRate: 25, Duration: 2}
+
+	jitter := gomovie.FrameTransform{
+		Transform: func(f *gomovie.Frame) {
+			time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+		},
+	}
+
+	frames := gomovie.NewFrameTransformer(gomovie.NewTestPatternClip(info)).AddTransform(jitter)
+	stream := gomovie.NewFrameTransformer(gomovie.NewTestPatternClip(info)).AddTransform(jitter)
+
+	var expected []byte
+	for i := 0; ; i++ {
+		f, err := frames.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Index != i {
+			t.Fatalf("Expected frame %v but got %v", i, f.Index)
+		}
+		expected = append(expected, f.Data...)
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, stream); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Fatal("Read and ReadFrame return different frames")
+	}
+}
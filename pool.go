@@ -0,0 +1,146 @@
+package gomovie
+
+import "sync"
+
+// FramePool reuses the data of frames of a single size so reading doesn't allocate a new buffer for every frame.
+// Frames from the pool should be released with Frame.Release once they are no longer used. Safe for concurrent use.
+type FramePool struct {
+	width, height int
+	pool          sync.Pool
+}
+
+// NewFramePool creates a pool of width x height frames
+func NewFramePool(width, height int) *FramePool {
+	p := &FramePool{width: width, height: height}
+	p.pool.New = func() interface{} {
+		return &Frame{Data: make([]byte, width*height*4), Width: width, Height: height, pool: p}
+	}
+	return p
+}
+
+// Get returns a frame of the size of the pool. The data is not cleared.
+func (p *FramePool) Get() *Frame {
+	f := p.pool.Get().(*Frame)
+	f.Index, f.Time, f.released = 0, 0, false
+	return f
+}
+
+// Release returns the frame to its pool. The frame and its data should not be used afterwards.
+// Does nothing for frames which are not from a pool or are already released.
+func (f *Frame) Release() {
+	if f.pool == nil || f.released {
+		return
+	}
+
+	//the data might have been replaced by a transform
+	if len(f.Data) != f.pool.width*f.pool.height*4 {
+		return
+	}
+
+	f.released = true
+	f.Width, f.Height = f.pool.width, f.pool.height
+	f.pool.pool.Put(f)
+}
+
+// frameData returns data with the size of a w x h frame. The data is reused when it is large enough.
+func frameData(data []byte, w, h int) []byte {
+	n := w * h * 4
+	if cap(data) < n {
+		return make([]byte, n)
+	}
+	return data[:n]
+}
+
+// FrameIntoReader is implemented by FrameReaders which can read a frame into an existing frame without allocating
+type FrameIntoReader interface {
+	ReadFrameInto(f *Frame) error
+}
+
+// ReadFrameInto reads the next frame of the reader into f. The data of f is reused when it is large enough.
+// Readers which don't implement FrameIntoReader are read with ReadFrame and their data is copied.
+func ReadFrameInto(reader FrameReader, f *Frame) error {
+	if r, ok := reader.(FrameIntoReader); ok {
+		return r.ReadFrameInto(f)
+	}
+
+	src, err := reader.ReadFrame()
+	if err != nil {
+		return err
+	}
+
+	f.Data = frameData(f.Data, src.Width, src.Height)
+	copy(f.Data, src.Data)
+	f.Width, f.Height, f.Index, f.Time = src.Width, src.Height, src.Index, src.Time
+
+	return nil
+}
+
+// SampleBlockPool reuses the data of sample blocks of a single format and number of channels.
+// Blocks from the pool should be released with SampleBlock.Release once they are no longer used. Safe for concurrent use.
+type SampleBlockPool struct {
+	format   *SampleFormat
+	channels int
+	samples  int
+	pool     sync.Pool
+}
+
+// NewSampleBlockPool creates a pool of blocks with the BlockSize of the format. The format should not be changed afterwards.
+func NewSampleBlockPool(format *SampleFormat, channels int) *SampleBlockPool {
+	frameSize := format.BytesPerSample() * channels
+
+	p := &SampleBlockPool{
+		format:   format,
+		channels: channels,
+		samples:  intMax(format.BlockSize/frameSize, 1) * channels,
+	}
+
+	p.pool.New = func() interface{} {
+		data := makeSampleData(format, p.samples)
+		return &SampleBlock{SampleFormat: format, Data: data, Channels: channels, pool: p, full: data}
+	}
+
+	return p
+}
+
+// Get returns a block with n samples (of all channels). The data is not cleared. Blocks larger than the BlockSize are not pooled.
+func (p *SampleBlockPool) Get(n int) *SampleBlock {
+	if n > p.samples {
+		return &SampleBlock{SampleFormat: p.format, Data: makeSampleData(p.format, n), Channels: p.channels}
+	}
+
+	sb := p.pool.Get().(*SampleBlock)
+	sb.Time, sb.Duration, sb.released = 0, 0, false
+
+	sb.Data = sb.full
+	if n < p.samples {
+		sb.Data = sliceSampleData(sb.full, n)
+	}
+
+	return sb
+}
+
+// Release returns the block to its pool. The block and its data should not be used afterwards.
+// Does nothing for blocks which are not from a pool or are already released.
+func (sb *SampleBlock) Release() {
+	if sb.pool == nil || sb.released {
+		return
+	}
+
+	sb.released = true
+	sb.pool.pool.Put(sb)
+}
+
+// sliceSampleData returns the first n samples of the sample data
+func sliceSampleData(data interface{}, n int) interface{} {
+	switch d := data.(type) {
+	case []SampleInt16:
+		return d[:n]
+	case []SampleInt24:
+		return d[:n]
+	case []SampleInt32:
+		return d[:n]
+	case []SampleFloat32:
+		return d[:n]
+	}
+	return data
+}
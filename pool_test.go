@@ -0,0 +1,204 @@
+package gomovie_test
+
+import (
+	"bytes"
+	"image/color"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestFramePool(t *testing.T) {
+	pool := gomovie.NewFramePool(4, 2)
+
+	f := pool.Get()
+	if len(f.Data) != 32 || f.Width != 4 || f.Height != 2 {
+		t.Fatalf("Unexpected frame %vx%v with %v bytes", f.Width, f.Height, len(f.Data))
+	}
+
+	f.Release()
+	f.Release()
+
+	//frames which are not from a pool are ignored
+	(&gomovie.Frame{}).Release()
+
+	if f = pool.Get(); len(f.Data) != 32 {
+		t.Fatalf("Expected 32 bytes but got %v", len(f.Data))
+	}
+}
+
+func TestReadFrameInto(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 32, Height: 16, FrameRate: 25, Duration: 1}
+	a, b := gomovie.NewTestPatternClip(info), gomovie.NewTestPatternClip(info)
+
+	f := &gomovie.Frame{}
+	var data *byte
+
+	for i := 0; i < 25; i++ {
+		expected, err := a.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := gomovie.ReadFrameInto(b, f); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(f.Data, expected.Data) || f.Index != expected.Index || f.Time != expected.Time {
+			t.Fatalf("Frame %v differs", i)
+		}
+
+		if data != nil && data != &f.Data[0] {
+			t.Fatal("Expected the data to be reused")
+		}
+		data = &f.Data[0]
+	}
+
+	if err := gomovie.ReadFrameInto(b, f); err != io.EOF {
+		t.Fatalf("Expected EOF but got %v", err)
+	}
+}
+
+func TestReadFrameIntoCopy(t *testing.T) {
+	reader := gomovie.NewFrameTransformer(colorClip(color.White, 1))
+
+	f := &gomovie.Frame{}
+	if err := gomovie.ReadFrameInto(reader, f); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(f.Data) != 8*8*4 || f.Data[0] != 255 {
+		t.Fatalf("Unexpected frame data %v", f.Data[:4])
+	}
+}
+
+func TestSampleBlockPool(t *testing.T) {
+	format := &gomovie.SampleFormat{Depth: 24, BlockSize: 1200}
+	pool := gomovie.NewSampleBlockPool(format, 2)
+
+	sb := pool.Get(100)
+	if sb.Len() != 100 {
+		t.Fatalf("Expected 100 samples but got %v", sb.Len())
+	}
+
+	for i := range sb.Int24() {
+		sb.Int24()[i] = gomovie.SampleInt24(i * 1000)
+	}
+
+	if !bytes.Equal(sb.AppendBytes([]byte{1}), append([]byte{1}, sb.Bytes()...)) {
+		t.Fatal("Expected AppendBytes to append the bytes")
+	}
+
+	sb.Release()
+
+	if sb = pool.Get(200); sb.Len() != 200 {
+		t.Fatalf("Expected 200 samples but got %v", sb.Len())
+	}
+}
+
+func benchmarkReadFrames(b *testing.B, read func(reader gomovie.FrameReader) error) {
+	info := &gomovie.FrameReaderInfo{Width: 1920, Height: 1080, FrameRate: 25, Duration: 1}
+	reader := gomovie.NewTestPatternClip(info)
+
+	b.ReportAllocs()
+	b.SetBytes(1920 * 1080 * 4)
+
+	for i := 0; i < b.N; i++ {
+		if err := read(reader); err == io.EOF {
+			reader = gomovie.NewTestPatternClip(info)
+		} else if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadFrame(b *testing.B) {
+	benchmarkReadFrames(b, func(reader gomovie.FrameReader) error {
+		_, err := reader.ReadFrame()
+		return err
+	})
+}
+
+func BenchmarkReadFrameInto(b *testing.B) {
+	f := &gomovie.Frame{}
+	benchmarkReadFrames(b, func(reader gomovie.FrameReader) error {
+		return gomovie.ReadFrameInto(reader, f)
+	})
+}
+
+func benchmarkFfmpegFrames(b *testing.B, config gomovie.OpenConfig, read func(reader gomovie.FrameReader) error) {
+	path := os.Getenv("GOMOVIE_VIDEO")
+	if path == "" {
+		b.Skip("GOMOVIE_VIDEO not set!")
+	}
+
+	open := func() gomovie.FrameReader {
+		v, err := gomovie.FfmpegOpenWithConfig(path, config)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return v.FrameReader
+	}
+
+	reader := open()
+	info := reader.Info()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(info.Width * info.Height * 4))
+
+	for i := 0; i < b.N; i++ {
+		if err := read(reader); err == io.EOF {
+			reader.Close()
+			reader = open()
+		} else if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	reader.Close()
+}
+
+func BenchmarkFfmpegReadFrame(b *testing.B) {
+	benchmarkFfmpegFrames(b, gomovie.OpenConfig{}, func(reader gomovie.FrameReader) error {
+		_, err := reader.ReadFrame()
+		return err
+	})
+}
+
+func BenchmarkFfmpegReadFramePooled(b *testing.B) {
+	benchmarkFfmpegFrames(b, gomovie.OpenConfig{Pooled: true}, func(reader gomovie.FrameReader) error {
+		f, err := reader.ReadFrame()
+		if err == nil {
+			f.Release()
+		}
+		return err
+	})
+}
+
+func BenchmarkFfmpegReadFrameInto(b *testing.B) {
+	f := &gomovie.Frame{}
+	benchmarkFfmpegFrames(b, gomovie.OpenConfig{}, func(reader gomovie.FrameReader) error {
+		return gomovie.ReadFrameInto(reader, f)
+	})
+}
+
+func BenchmarkSampleBlockBytes(b *testing.B) {
+	sb := gomovie.NewSampleBlockPool(gomovie.NewSampleFormat(), 2).Get(4096)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		sb.Bytes()
+	}
+}
+
+func BenchmarkSampleBlockAppendBytes(b *testing.B) {
+	sb := gomovie.NewSampleBlockPool(gomovie.NewSampleFormat(), 2).Get(4096)
+	b.ReportAllocs()
+
+	var buf []byte
+	for i := 0; i < b.N; i++ {
+		buf = sb.AppendBytes(buf[:0])
+	}
+}
@@ -0,0 +1,349 @@
+package gomovie
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+)
+
+// MaxPSNR is reported for identical frames instead of +Inf so the report can be encoded as JSON
+const MaxPSNR = 100.
+
+// FrameAlignment describes how the frames of two readers are paired by Compare
+type FrameAlignment int
+
+const (
+	// AlignByIndex pairs the frames in the order they are read
+	AlignByIndex FrameAlignment = iota
+	// AlignByTime pairs each frame with the frame of the other reader which is closest in time. Use it for different frame rates.
+	AlignByTime
+)
+
+// CompareConfig describes the options of CompareWithConfig
+type CompareConfig struct {
+	Align FrameAlignment
+
+	// SkipMSSSIM skips the multi scale SSIM which is the slowest metric
+	SkipMSSSIM bool
+}
+
+// FrameQuality contains the metrics of a single frame
+type FrameQuality struct {
+	Index int     `json:"index"`
+	Time  float32 `json:"time"`
+
+	// PSNR in dB over the red, green and blue channels. MaxPSNR when the frames are identical.
+	PSNR float64 `json:"psnr"`
+
+	// SSIM and MSSSIM of the luma between 0 and 1. 1 means identical.
+	SSIM   float64 `json:"ssim"`
+	MSSSIM float64 `json:"ms_ssim,omitempty"`
+
+	mse float64
+}
+
+// QualityReport contains the metrics of each frame and the aggregate of all frames
+type QualityReport struct {
+	Frames []FrameQuality `json:"frames"`
+
+	// PSNR of the mean squared error of all the frames
+	PSNR float64 `json:"psnr"`
+
+	// MinPSNR is the PSNR of the worst frame
+	MinPSNR float64 `json:"min_psnr"`
+
+	// SSIM and MSSSIM are the mean of all the frames
+	SSIM    float64 `json:"ssim"`
+	MinSSIM float64 `json:"min_ssim"`
+	MSSSIM  float64 `json:"ms_ssim,omitempty"`
+}
+
+// JSON encodes the report as indented JSON
+func (r *QualityReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Compare compares the frames of b (the distorted frames) with a (the reference) aligned by index
+func Compare(a, b FrameReader) (*QualityReport, error) {
+	return CompareWithConfig(a, b, CompareConfig{})
+}
+
+// CompareWithConfig compares the frames of b (the distorted frames) with a (the reference). The frames of b are resized to the size
+// of a when they differ. The comparison stops at the end of either reader.
+func CompareWithConfig(a, b FrameReader, config CompareConfig) (*QualityReport, error) {
+	report := &QualityReport{MinPSNR: MaxPSNR, MinSSIM: 1}
+
+	var align func(t float32) (*Frame, error)
+	if config.Align == AlignByTime {
+		align = newTimeAligner(b)
+	}
+
+	var mse float64
+
+	for {
+		fa, err := a.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var fb *Frame
+		if align != nil {
+			fb, err = align(fa.Time)
+		} else {
+			fb, err = b.ReadFrame()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		q := compareFrames(fa, fb, !config.SkipMSSSIM)
+
+		report.Frames = append(report.Frames, q)
+		mse += q.mse
+		report.SSIM += q.SSIM
+		report.MSSSIM += q.MSSSIM
+		report.MinPSNR = math.Min(report.MinPSNR, q.PSNR)
+		report.MinSSIM = math.Min(report.MinSSIM, q.SSIM)
+	}
+
+	if n := float64(len(report.Frames)); n > 0 {
+		report.PSNR = psnr(mse / n)
+		report.SSIM /= n
+		report.MSSSIM /= n
+	}
+
+	return report, nil
+}
+
+// newTimeAligner returns a function which returns the frame of the reader closest to time t. t should increase with each call.
+func newTimeAligner(reader FrameReader) func(t float32) (*Frame, error) {
+	var cur, next *Frame
+	var eof bool
+
+	read := func() error {
+		f, err := reader.ReadFrame()
+		if err == io.EOF {
+			eof, next = true, nil
+			return nil
+		}
+		next = f
+		return err
+	}
+
+	return func(t float32) (*Frame, error) {
+		if cur == nil && !eof {
+			if err := read(); err != nil {
+				return nil, err
+			}
+			cur = next
+			if err := read(); err != nil {
+				return nil, err
+			}
+		}
+
+		for next != nil && next.Time <= t {
+			cur = next
+			if err := read(); err != nil {
+				return nil, err
+			}
+		}
+
+		if cur == nil {
+			return nil, io.EOF
+		}
+
+		if next != nil && next.Time-t < t-cur.Time {
+			return next, nil
+		}
+		return cur, nil
+	}
+}
+
+func psnr(mse float64) float64 {
+	if mse <= 0 {
+		return MaxPSNR
+	}
+	return math.Min(10*math.Log10(255*255/mse), MaxPSNR)
+}
+
+// lumaPlane returns the luma of each pixel of RGBA data
+func lumaPlane(data []byte) []float64 {
+	y := make([]float64, len(data)/4)
+	for i := range y {
+		p := data[i*4:]
+		y[i] = .299*float64(p[0]) + .587*float64(p[1]) + .114*float64(p[2])
+	}
+	return y
+}
+
+func compareFrames(a, b *Frame, multiScale bool) FrameQuality {
+	bd := b.Data
+	if b.Width != a.Width || b.Height != a.Height {
+		bd = resizeRGBA(b.Data, b.Width, b.Height, a.Width, a.Height, Bilinear)
+	}
+
+	q := FrameQuality{Index: a.Index, Time: a.Time}
+
+	var sum float64
+	for i := 0; i+4 <= len(a.Data) && i+4 <= len(bd); i += 4 {
+		for c := 0; c < 3; c++ {
+			d := float64(a.Data[i+c]) - float64(bd[i+c])
+			sum += d * d
+		}
+	}
+
+	if pixels := a.Width * a.Height; pixels > 0 {
+		q.mse = sum / float64(pixels*3)
+	}
+	q.PSNR = psnr(q.mse)
+
+	ya, yb := lumaPlane(a.Data), lumaPlane(bd)
+
+	q.SSIM, _ = ssim(ya, yb, a.Width, a.Height)
+	if multiScale {
+		q.MSSSIM = msssim(ya, yb, a.Width, a.Height)
+	}
+
+	return q
+}
+
+// gaussian window of 11 values with a sigma of 1.5 as used by the SSIM paper
+var ssimWindow = func() []float64 {
+	w := make([]float64, 11)
+	var sum float64
+	for i := range w {
+		x := float64(i - 5)
+		w[i] = math.Exp(-x * x / (2 * 1.5 * 1.5))
+		sum += w[i]
+	}
+	for i := range w {
+		w[i] /= sum
+	}
+	return w
+}()
+
+// blur applies the window horizontally and vertically. The edges are clamped.
+func blur(src []float64, w, h int, window []float64) []float64 {
+	r := len(window) / 2
+	tmp := make([]float64, len(src))
+	dst := make([]float64, len(src))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var v float64
+			for k, g := range window {
+				sx := intMin(intMax(x+k-r, 0), w-1)
+				v += src[y*w+sx] * g
+			}
+			tmp[y*w+x] = v
+		}
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var v float64
+			for k, g := range window {
+				sy := intMin(intMax(y+k-r, 0), h-1)
+				v += tmp[sy*w+x] * g
+			}
+			dst[y*w+x] = v
+		}
+	}
+
+	return dst
+}
+
+// ssim returns the mean structural similarity and the mean of the contrast and structure part of two planes (0 - 255)
+func ssim(a, b []float64, w, h int) (float64, float64) {
+	if w == 0 || h == 0 {
+		return 1, 1
+	}
+
+	const (
+		c1 = (.01 * 255) * (.01 * 255)
+		c2 = (.03 * 255) * (.03 * 255)
+	)
+
+	aa := make([]float64, len(a))
+	bb := make([]float64, len(a))
+	ab := make([]float64, len(a))
+	for i := range a {
+		aa[i] = a[i] * a[i]
+		bb[i] = b[i] * b[i]
+		ab[i] = a[i] * b[i]
+	}
+
+	muA, muB := blur(a, w, h, ssimWindow), blur(b, w, h, ssimWindow)
+	sAA, sBB, sAB := blur(aa, w, h, ssimWindow), blur(bb, w, h, ssimWindow), blur(ab, w, h, ssimWindow)
+
+	var sumSSIM, sumCS float64
+	for i := range a {
+		varA := sAA[i] - muA[i]*muA[i]
+		varB := sBB[i] - muB[i]*muB[i]
+		cov := sAB[i] - muA[i]*muB[i]
+
+		cs := (2*cov + c2) / (varA + varB + c2)
+		l := (2*muA[i]*muB[i] + c1) / (muA[i]*muA[i] + muB[i]*muB[i] + c1)
+
+		sumSSIM += l * cs
+		sumCS += cs
+	}
+
+	n := float64(len(a))
+	return sumSSIM / n, sumCS / n
+}
+
+// halve downscales the plane by averaging blocks of 2x2 pixels
+func halve(src []float64, w, h int) ([]float64, int, int) {
+	dw, dh := w/2, h/2
+	dst := make([]float64, dw*dh)
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			o := y*2*w + x*2
+			dst[y*dw+x] = (src[o] + src[o+1] + src[o+w] + src[o+w+1]) / 4
+		}
+	}
+	return dst, dw, dh
+}
+
+// weights of the 5 scales of MS-SSIM
+var msssimWeights = []float64{.0448, .2856, .3001, .2363, .1333}
+
+// msssim returns the multi scale SSIM. Small frames use fewer scales (at least the size of the window at the smallest scale).
+func msssim(a, b []float64, w, h int) float64 {
+	scales := 1
+	for scales < len(msssimWeights) && intMin(w, h)>>uint(scales) >= len(ssimWindow) {
+		scales++
+	}
+
+	weights := msssimWeights[:scales]
+	var total float64
+	for _, v := range weights {
+		total += v
+	}
+
+	result := 1.
+	for s := 0; s < scales; s++ {
+		full, cs := ssim(a, b, w, h)
+
+		v := cs
+		if s == scales-1 {
+			v = full
+		}
+
+		result *= math.Pow(math.Max(v, 0), weights[s]/total)
+
+		if s < scales-1 {
+			a, _, _ = halve(a, w, h)
+			b, w, h = halve(b, w, h)
+		}
+	}
+
+	return result
+}
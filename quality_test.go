@@ -0,0 +1,91 @@
+package gomovie_test
+
+import (
+	"encoding/json"
+	"image/color"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestCompareIdentical(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 64, Height: 64, FrameRate: 25, Duration: .4}
+
+	report, err := gomovie.Compare(gomovie.NewTestPatternClip(info), gomovie.NewTestPatternClip(info))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Frames) != 10 {
+		t.Fatalf("Expected 10 frames but got %v", len(report.Frames))
+	}
+
+	if report.PSNR != gomovie.MaxPSNR || report.SSIM < .9999 || report.MSSSIM < .9999 {
+		t.Fatalf("Expected identical frames but got %+v", report)
+	}
+
+	if _, err := report.JSON(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompareDistorted(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 64, Height: 64, FrameRate: 25, Duration: .2}
+	reference := gomovie.NewCheckerboardClip(4, color.Black, color.White, info)
+
+	//a blur by down and upscaling
+	blurred := gomovie.NewFrameTransformer(gomovie.NewCheckerboardClip(4, color.Black, color.White, info)).
+		AddTransform(gomovie.NewResizeTransform(16, 16, gomovie.DefaultFit)).
+		AddTransform(gomovie.NewResizeTransform(64, 64, gomovie.DefaultFit))
+
+	report, err := gomovie.Compare(reference, blurred)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.PSNR > 20 || report.SSIM > .5 {
+		t.Fatalf("Expected a low quality but got %v dB and %v", report.PSNR, report.SSIM)
+	}
+}
+
+func TestCompareKnownPSNR(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 16, Height: 16, FrameRate: 25, Duration: .2}
+
+	//a difference of 10 on every channel gives an mse of 100
+	report, err := gomovie.Compare(
+		gomovie.NewColorClip(color.NRGBA{100, 100, 100, 255}, info),
+		gomovie.NewColorClip(color.NRGBA{110, 110, 110, 255}, info),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := report.PSNR - 28.1308; d > .001 || d < -.001 {
+		t.Fatalf("Expected 28.13 dB but got %v", report.PSNR)
+	}
+}
+
+func TestCompareAlignByTime(t *testing.T) {
+	//the fade only depends on the time of the frame
+	fade := func(frameRate float32) gomovie.FrameReader {
+		clip := gomovie.NewColorClip(color.White, &gomovie.FrameReaderInfo{Width: 16, Height: 16, FrameRate: frameRate, Duration: 1})
+		return gomovie.FadeFrames(clip, 1, 0, gomovie.FadeLinear, color.Black)
+	}
+
+	a, b := fade(25), fade(50)
+
+	report, err := gomovie.CompareWithConfig(a, b, gomovie.CompareConfig{Align: gomovie.AlignByTime, SkipMSSSIM: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Frames) != 25 || report.MinPSNR != gomovie.MaxPSNR {
+		t.Fatalf("Expected the frames at the same time to be identical but got %v", report.MinPSNR)
+	}
+
+	var decoded gomovie.QualityReport
+	data, _ := report.JSON()
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Frames) != 25 {
+		t.Fatalf("Could not decode the report: %v", err)
+	}
+}
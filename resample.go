@@ -0,0 +1,372 @@
+package gomovie
+
+import (
+	"io"
+	"math"
+)
+
+// floatSampleBase implements the block and byte handling for readers which produce interleaved float frames
+type floatSampleBase struct {
+	i *SampleReaderInfo
+	o *SampleFormat
+
+	offset int //number of frames returned
+	l      []byte
+}
+
+func (b *floatSampleBase) Info() *SampleReaderInfo     { return b.i }
+func (b *floatSampleBase) SampleFormat() *SampleFormat { return b.o }
+
+// blockFrames returns the number of frames which fit in a single block
+func (b *floatSampleBase) blockFrames() int {
+	return intMax(b.o.BlockSize/(b.o.BytesPerSample()*b.i.Channels), 1)
+}
+
+// block converts the frames to a SampleBlock and advances the time
+func (b *floatSampleBase) block(frames []float32) *SampleBlock {
+	n := len(frames) / b.i.Channels
+
+	sb := newSampleBlock(b.o, b.i.Channels, frames, float32(b.offset)/float32(b.i.SampleRate), float32(n)/float32(b.i.SampleRate))
+
+	b.offset += n
+	return sb
+}
+
+// read implements io.Reader with the blocks returned by next
+func (b *floatSampleBase) read(p []byte, next func() (*SampleBlock, error)) (n int, err error) {
+	if len(b.l) == 0 {
+		var sb *SampleBlock
+		if sb, err = next(); err != nil {
+			return
+		}
+		b.l = sb.Bytes()
+	}
+
+	n = copy(p, b.l)
+	b.l = b.l[n:]
+	return
+}
+
+// ResampleQuality describes the length of the resampling filter
+type ResampleQuality int
+
+const (
+	ResampleLow ResampleQuality = iota
+	ResampleMedium
+	ResampleHigh
+)
+
+// params returns the number of zero crossings of the sinc on each side and the beta of the kaiser window
+func (q ResampleQuality) params() (int, float64) {
+	switch q {
+	case ResampleLow:
+		return 8, 5
+	case ResampleHigh:
+		return 32, 9
+	}
+	return 16, 7
+}
+
+// number of precalculated filter values for each input sample
+const resamplePhases = 256
+
+// Resampler converts a SampleReader to another sample rate with a kaiser windowed sinc filter.
+// implements the SampleReader interface.
+type Resampler struct {
+	floatSampleBase
+
+	src     SampleReader
+	quality ResampleQuality
+
+	input    *floatFrameReader
+	channels int
+	step     float64 //input frames per output frame
+
+	halfWidth float64 //half the width of the filter in input frames
+	table     []float32
+
+	buf      []float32
+	bufStart int //index of the first frame in buf
+	eof      bool
+}
+
+// NewResampler creates a Resampler which converts the src to the sample rate
+func NewResampler(src SampleReader, sampleRate int, quality ResampleQuality) *Resampler {
+	info := *src.Info()
+	info.SampleRate = sampleRate
+
+	return &Resampler{
+		floatSampleBase: floatSampleBase{i: &info, o: NewSampleFormat()},
+		src:             src,
+		quality:         quality,
+	}
+}
+
+func (rs *Resampler) Range() *Range { return rs.src.Range() }
+func (rs *Resampler) Close() error  { return rs.src.Close() }
+
+func (rs *Resampler) Slice(r *Range) SampleReader {
+	s := NewResampler(rs.src.Slice(r), rs.i.SampleRate, rs.quality)
+	*s.o = *rs.o
+	return s
+}
+
+func (rs *Resampler) init() {
+	inRate := rs.src.Info().SampleRate
+
+	rs.input = newFloatFrameReader(rs.src)
+	rs.channels = rs.src.Info().Channels
+	rs.step = float64(inRate) / float64(rs.i.SampleRate)
+
+	//lower the cutoff when downsampling to prevent aliasing
+	cutoff := math.Min(1, 1/rs.step)
+
+	zeroCrossings, beta := rs.quality.params()
+	rs.halfWidth = float64(zeroCrossings) / cutoff
+
+	rs.table = make([]float32, int(math.Ceil(rs.halfWidth*resamplePhases))+2)
+	i0beta := besselI0(beta)
+
+	for k := range rs.table {
+		t := float64(k) / resamplePhases
+
+		var v float64
+		if t < rs.halfWidth {
+			x := t / rs.halfWidth
+			v = cutoff * sinc(cutoff*t) * besselI0(beta*math.Sqrt(1-x*x)) / i0beta
+		}
+		rs.table[k] = float32(v)
+	}
+}
+
+// filter returns the filter value at distance t (in input frames)
+func (rs *Resampler) filter(t float64) float32 {
+	p := math.Abs(t) * resamplePhases
+	k := int(p)
+	if k+1 >= len(rs.table) {
+		return 0
+	}
+	f := float32(p - float64(k))
+	return rs.table[k]*(1-f) + rs.table[k+1]*f
+}
+
+// fill makes sure the frames up to (not including) end are buffered when available
+func (rs *Resampler) fill(end int) error {
+	for !rs.eof && rs.bufStart+len(rs.buf)/rs.channels < end {
+		f, err := rs.input.read(1024)
+		if err == io.EOF {
+			rs.eof = true
+			break
+		}
+		if err != nil {
+			return err
+		}
+		rs.buf = append(rs.buf, f...)
+	}
+	return nil
+}
+
+func (rs *Resampler) ReadSampleBlock() (*SampleBlock, error) {
+	if rs.input == nil {
+		rs.init()
+	}
+
+	total := int(math.Floor(float64(sampleReaderDuration(rs.src))*float64(rs.i.SampleRate) + 1e-3))
+
+	n := intMin(rs.blockFrames(), total-rs.offset)
+	if n <= 0 {
+		return nil, io.EOF
+	}
+
+	ch := rs.channels
+	hw := int(math.Ceil(rs.halfWidth))
+	out := make([]float32, 0, n*ch)
+
+	for i := 0; i < n; i++ {
+		x := float64(rs.offset+i) * rs.step
+		center := int(math.Floor(x))
+
+		if err := rs.fill(center + hw + 1); err != nil {
+			return nil, err
+		}
+
+		available := rs.bufStart + len(rs.buf)/ch
+		if rs.eof && center >= available {
+			n = i
+			break
+		}
+
+		frame := make([]float32, ch)
+
+		for k := intMax(center-hw+1, rs.bufStart); k <= center+hw && k < available; k++ {
+			w := rs.filter(x - float64(k))
+			if w == 0 {
+				continue
+			}
+
+			o := (k - rs.bufStart) * ch
+			for c := 0; c < ch; c++ {
+				frame[c] += rs.buf[o+c] * w
+			}
+		}
+
+		out = append(out, frame...)
+	}
+
+	if n == 0 {
+		return nil, io.EOF
+	}
+
+	//drop the frames which are no longer needed for the next block
+	next := int(math.Floor(float64(rs.offset+n)*rs.step)) - hw + 1
+	if drop := intMin(next-rs.bufStart, len(rs.buf)/ch); drop > 0 {
+		rs.buf = rs.buf[drop*ch:]
+		rs.bufStart += drop
+	}
+
+	return rs.block(out), nil
+}
+
+func (rs *Resampler) Read(p []byte) (int, error) {
+	return rs.read(p, rs.ReadSampleBlock)
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	px := math.Pi * x
+	return math.Sin(px) / px
+}
+
+// besselI0 is the modified bessel function of the first kind of order 0
+func besselI0(x float64) float64 {
+	sum, term := 1., 1.
+	for k := 1; k < 50; k++ {
+		term *= (x / 2) * (x / 2) / float64(k*k)
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+	return sum
+}
+
+// DefaultChannelMatrix returns the mixing matrix (one row of input gains for each output channel) for converting between channel counts.
+// Mono is copied to every channel and downmixed to mono by averaging. 5.1 (FL FR FC LFE BL BR) is downmixed to stereo with the
+// ITU-R BS.775 coefficients (center and surround at -3 dB, LFE is dropped). Other conversions keep the channels both sides have in common.
+func DefaultChannelMatrix(in, out int) [][]float64 {
+	m := make([][]float64, out)
+	for o := range m {
+		m[o] = make([]float64, in)
+	}
+
+	const minus3dB = 0.7071067811865476
+
+	switch {
+	case in == 1:
+		for o := range m {
+			m[o][0] = 1
+		}
+	case in == 6 && out == 2:
+		m[0][0], m[0][2], m[0][4] = 1, minus3dB, minus3dB
+		m[1][1], m[1][2], m[1][5] = 1, minus3dB, minus3dB
+	case in == 6 && out == 1:
+		m[0][0], m[0][1], m[0][2], m[0][4], m[0][5] = .5, .5, minus3dB, minus3dB/2, minus3dB/2
+	case out == 1:
+		for i := range m[0] {
+			m[0][i] = 1 / float64(in)
+		}
+	default:
+		for c := 0; c < in && c < out; c++ {
+			m[c][c] = 1
+		}
+	}
+
+	return m
+}
+
+// ChannelMapper converts the channels of a SampleReader with a mixing matrix. implements the SampleReader interface.
+type ChannelMapper struct {
+	floatSampleBase
+
+	src    SampleReader
+	matrix [][]float64
+	input  *floatFrameReader
+}
+
+// NewChannelMapper creates a ChannelMapper with the matrix. Each row contains the gains of the input channels for an output channel.
+func NewChannelMapper(src SampleReader, matrix [][]float64) *ChannelMapper {
+	info := *src.Info()
+	info.Channels = len(matrix)
+
+	return &ChannelMapper{
+		floatSampleBase: floatSampleBase{i: &info, o: NewSampleFormat()},
+		src:             src,
+		matrix:          matrix,
+	}
+}
+
+// NewChannelConverter creates a ChannelMapper to the number of channels using DefaultChannelMatrix
+func NewChannelConverter(src SampleReader, channels int) *ChannelMapper {
+	return NewChannelMapper(src, DefaultChannelMatrix(src.Info().Channels, channels))
+}
+
+func (cm *ChannelMapper) Range() *Range { return cm.src.Range() }
+func (cm *ChannelMapper) Close() error  { return cm.src.Close() }
+
+func (cm *ChannelMapper) Slice(r *Range) SampleReader {
+	s := NewChannelMapper(cm.src.Slice(r), cm.matrix)
+	*s.o = *cm.o
+	return s
+}
+
+func (cm *ChannelMapper) ReadSampleBlock() (*SampleBlock, error) {
+	if cm.input == nil {
+		cm.input = newFloatFrameReader(cm.src)
+	}
+
+	in := cm.input.channels
+	out := len(cm.matrix)
+
+	frames, err := cm.input.read(cm.blockFrames())
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(frames) / in
+	mapped := make([]float32, n*out)
+
+	for i := 0; i < n; i++ {
+		for o, row := range cm.matrix {
+			var v float64
+			for c, g := range row {
+				if c < in {
+					v += float64(frames[i*in+c]) * g
+				}
+			}
+			mapped[i*out+o] = float32(v)
+		}
+	}
+
+	return cm.block(mapped), nil
+}
+
+func (cm *ChannelMapper) Read(p []byte) (int, error) {
+	return cm.read(p, cm.ReadSampleBlock)
+}
+
+// convertSampleReader wraps the reader with a ChannelMapper and Resampler when it does not match the sample rate or channel count
+func convertSampleReader(reader SampleReader, sampleRate, channels int) SampleReader {
+	info := reader.Info()
+
+	if info.Channels != channels {
+		reader = NewChannelConverter(reader, channels)
+	}
+
+	if info.SampleRate != sampleRate {
+		reader = NewResampler(reader, sampleRate, ResampleMedium)
+	}
+
+	return reader
+}
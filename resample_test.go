@@ -0,0 +1,81 @@
+package gomovie_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestResampler(t *testing.T) {
+	src := gomovie.NewToneReader(gomovie.Sine, 1000, .5, &gomovie.SampleReaderInfo{SampleRate: 44100, Channels: 1, Duration: 1})
+	resampled := gomovie.NewResampler(src, 48000, gomovie.ResampleHigh)
+
+	samples := readFloats(t, resampled)
+	if len(samples) != 48000 {
+		t.Fatalf("Expected 48000 samples but got %v", len(samples))
+	}
+
+	//compare with a tone generated at 48 kHz (skip the edges of the filter)
+	var maxErr float64
+	for n := 1000; n < 47000; n++ {
+		expected := .5 * math.Sin(2*math.Pi*1000*float64(n)/48000)
+		maxErr = math.Max(maxErr, math.Abs(float64(samples[n])-expected))
+	}
+
+	if maxErr > .002 {
+		t.Fatalf("Resampled signal differs too much: %v", maxErr)
+	}
+}
+
+func TestChannelMapper(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 6, Duration: .1}
+	surround := gomovie.NewToneReader(gomovie.Square, 100, .25, info)
+
+	stereo := gomovie.NewChannelConverter(surround, 2)
+	if stereo.Info().Channels != 2 {
+		t.Fatal("Expected 2 channels")
+	}
+
+	//FL + FC * -3dB + BL * -3dB
+	expected := .25 * (1 + 2*math.Sqrt(.5))
+	if s := readFloats(t, stereo); math.Abs(float64(s[0])-expected) > .001 {
+		t.Fatalf("Expected %v but got %v", expected, s[0])
+	}
+}
+
+func TestConcatConvertsSampleRate(t *testing.T) {
+	a := gomovie.NewToneReader(gomovie.Sine, 440, .5, &gomovie.SampleReaderInfo{SampleRate: 44100, Channels: 1, Duration: 1})
+	b := gomovie.NewToneReader(gomovie.Sine, 440, .5, &gomovie.SampleReaderInfo{SampleRate: 48000, Channels: 2, Duration: 1})
+
+	samples := readFloats(t, gomovie.Concat(a, b).SampleReader)
+
+	if len(samples) != 2*96000 {
+		t.Fatalf("Expected %v samples but got %v", 2*96000, len(samples))
+	}
+}
+
+func TestResamplerRinging(t *testing.T) {
+	//a square wave is not band limited so the sinc filter rings on its edges
+	src := gomovie.NewToneReader(gomovie.Square, 1000, .5, &gomovie.SampleReaderInfo{SampleRate: 44100, Channels: 1, Duration: 1})
+
+	for _, quality := range []gomovie.ResampleQuality{gomovie.ResampleLow, gomovie.ResampleMedium, gomovie.ResampleHigh} {
+		samples := readFloats(t, gomovie.NewResampler(src.Slice(&gomovie.Range{Start: 0, Duration: 1}), 48000, quality))
+
+		var peak, sum float64
+		for n := 1000; n < 47000; n++ {
+			v := float64(samples[n])
+			peak = math.Max(peak, math.Abs(v))
+			sum += v * v
+		}
+		rms := math.Sqrt(sum / 46000)
+
+		//the energy is kept and the overshoot stays bounded (a naive square aliases so it is more than the 9% of gibbs)
+		if math.Abs(rms-.5) > .005 {
+			t.Fatalf("Expected the rms of the square to be kept at quality %v but got %v", quality, rms)
+		}
+		if peak < .5 || peak > .5*1.35 {
+			t.Fatalf("Expected a bounded overshoot at quality %v but got a peak of %v", quality, peak)
+		}
+	}
+}
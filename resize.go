@@ -0,0 +1,292 @@
+package gomovie
+
+import (
+	"image/color"
+	"math"
+)
+
+// ResizeFilter describes the interpolation filter which is used when scaling frames
+type ResizeFilter int
+
+const (
+	// NearestNeighbor picks the closest source pixel. Fast but blocky.
+	NearestNeighbor ResizeFilter = iota
+	// Bilinear uses a triangle filter
+	Bilinear
+	// Bicubic uses a Catmull-Rom cubic filter
+	Bicubic
+	// Lanczos uses a 3-lobed Lanczos filter. Sharpest result but also the slowest.
+	Lanczos
+)
+
+func (rf ResizeFilter) kernel() (support float64, fn func(x float64) float64) {
+	switch rf {
+	case Bilinear:
+		return 1, func(x float64) float64 {
+			x = math.Abs(x)
+			if x < 1 {
+				return 1 - x
+			}
+			return 0
+		}
+	case Bicubic:
+		return 2, func(x float64) float64 {
+			//catmull-rom (b=0, c=0.5)
+			x = math.Abs(x)
+			if x < 1 {
+				return 1.5*x*x*x - 2.5*x*x + 1
+			}
+			if x < 2 {
+				return -.5*x*x*x + 2.5*x*x - 4*x + 2
+			}
+			return 0
+		}
+	case Lanczos:
+		return 3, func(x float64) float64 {
+			x = math.Abs(x)
+			if x == 0 {
+				return 1
+			}
+			if x < 3 {
+				px := math.Pi * x
+				return 3 * math.Sin(px) * math.Sin(px/3) / (px * px)
+			}
+			return 0
+		}
+	}
+	return 0, nil
+}
+
+type resizeWeights struct {
+	start   int
+	weights []float32
+}
+
+// computes for each destination pixel the contributing source pixels and their weights
+func computeResizeWeights(dst, src int, filter ResizeFilter) []resizeWeights {
+	out := make([]resizeWeights, dst)
+	scale := float64(src) / float64(dst)
+
+	support, fn := filter.kernel()
+	if fn == nil { //nearest neighbor
+		for x := range out {
+			s := int((float64(x) + .5) * scale)
+			if s >= src {
+				s = src - 1
+			}
+			out[x] = resizeWeights{start: s, weights: []float32{1}}
+		}
+		return out
+	}
+
+	//when downscaling the filter should be stretched to prevent aliasing
+	filterScale := math.Max(scale, 1)
+	radius := support * filterScale
+
+	for x := range out {
+		center := (float64(x) + .5) * scale
+
+		start := int(math.Floor(center - radius))
+		if start < 0 {
+			start = 0
+		}
+
+		end := int(math.Ceil(center + radius))
+		if end > src {
+			end = src
+		}
+
+		weights := make([]float32, end-start)
+
+		var sum float64
+		for i := start; i < end; i++ {
+			w := fn((float64(i) + .5 - center) / filterScale)
+			weights[i-start] = float32(w)
+			sum += w
+		}
+
+		if sum != 0 {
+			for i := range weights {
+				weights[i] /= float32(sum)
+			}
+		}
+
+		out[x] = resizeWeights{start: start, weights: weights}
+	}
+
+	return out
+}
+
+// resizeRGBA scales the non premultiplied rgba data from sw x sh to dw x dh.
+// Filtering is done with premultiplied alpha so transparent pixels don't bleed their color.
+func resizeRGBA(src []byte, sw, sh, dw, dh int, filter ResizeFilter) []byte {
+	dst := make([]byte, dw*dh*4)
+
+	if sw == dw && sh == dh {
+		copy(dst, src)
+		return dst
+	}
+
+	//horizontal pass. Result is premultiplied floats with size dw x sh
+	xWeights := computeResizeWeights(dw, sw, filter)
+	tmp := make([]float32, dw*sh*4)
+
+	for y := 0; y < sh; y++ {
+		row := src[y*sw*4 : (y+1)*sw*4]
+		for x, xw := range xWeights {
+			var r, g, b, a float32
+			for i, w := range xw.weights {
+				p := row[(xw.start+i)*4:]
+				pa := float32(p[3]) * w
+				r += float32(p[0]) * pa
+				g += float32(p[1]) * pa
+				b += float32(p[2]) * pa
+				a += pa
+			}
+			o := (y*dw + x) * 4
+			tmp[o], tmp[o+1], tmp[o+2], tmp[o+3] = r/255, g/255, b/255, a
+		}
+	}
+
+	//vertical pass
+	yWeights := computeResizeWeights(dh, sh, filter)
+
+	for y, yw := range yWeights {
+		for x := 0; x < dw; x++ {
+			var r, g, b, a float32
+			for i, w := range yw.weights {
+				o := ((yw.start+i)*dw + x) * 4
+				r += tmp[o] * w
+				g += tmp[o+1] * w
+				b += tmp[o+2] * w
+				a += tmp[o+3] * w
+			}
+
+			o := (y*dw + x) * 4
+			if a <= 0 {
+				dst[o], dst[o+1], dst[o+2], dst[o+3] = 0, 0, 0, 0
+				continue
+			}
+
+			//unpremultiply
+			dst[o] = clampUint8(r / a * 255)
+			dst[o+1] = clampUint8(g / a * 255)
+			dst[o+2] = clampUint8(b / a * 255)
+			dst[o+3] = clampUint8(a)
+		}
+	}
+
+	return dst
+}
+
+func clampUint8(v float32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + .5)
+}
+
+// FitMode describes how a frame is placed inside a frame with a different size
+type FitMode int
+
+const (
+	// FitContain scales the frame so it fits completely. The remaining area (letterbox or pillarbox) is filled with the background color.
+	FitContain FitMode = iota
+	// FitCover scales the frame so it fills the whole area. Everything outside is cropped.
+	FitCover
+	// FitStretch scales the frame to the exact size ignoring the aspect ratio
+	FitStretch
+	// FitCenter does not scale the frame but only centers it
+	FitCenter
+)
+
+// Fit describes the policy for placing a frame inside a frame with a different size
+type Fit struct {
+	Mode   FitMode
+	Filter ResizeFilter
+
+	// Background is used for the area which is not covered by the frame. Transparent when nil.
+	Background color.Color
+}
+
+// DefaultFit is used by Concat when the frames of the readers have a different size
+var DefaultFit = Fit{Mode: FitContain, Filter: Bilinear, Background: color.Black}
+
+// placement returns the scaled size of the source and the offset in the destination
+func (fit Fit) placement(sw, sh, dw, dh int) (rw, rh, ox, oy int) {
+	switch fit.Mode {
+	case FitStretch:
+		return dw, dh, 0, 0
+	case FitCenter:
+		rw, rh = sw, sh
+	default:
+		sx := float64(dw) / float64(sw)
+		sy := float64(dh) / float64(sh)
+
+		s := math.Min(sx, sy)
+		if fit.Mode == FitCover {
+			s = math.Max(sx, sy)
+		}
+
+		rw = int(math.Floor(float64(sw)*s + .5))
+		rh = int(math.Floor(float64(sh)*s + .5))
+
+		if rw < 1 {
+			rw = 1
+		}
+		if rh < 1 {
+			rh = 1
+		}
+	}
+
+	ox = (dw - rw) / 2
+	oy = (dh - rh) / 2
+	return
+}
+
+// apply places the sw x sh rgba data in a new buffer of dw x dh
+func (fit Fit) apply(src []byte, sw, sh, dw, dh int) []byte {
+	rw, rh, ox, oy := fit.placement(sw, sh, dw, dh)
+
+	if rw != sw || rh != sh {
+		src = resizeRGBA(src, sw, sh, rw, rh, fit.Filter)
+	}
+
+	if rw == dw && rh == dh {
+		return src
+	}
+
+	dst := make([]byte, dw*dh*4)
+	if fit.Background != nil {
+		fillRGBA(dst, color.NRGBAModel.Convert(fit.Background).(color.NRGBA))
+	}
+
+	drawOverRGBA(dst, dw, dh, src, rw, rh, ox, oy, 255)
+
+	return dst
+}
+
+// NewResizeTransform creates a FrameTransform which scales each frame to width x height.
+// The fit decides what happens when the aspect ratio differs.
+// When width or height is 0 it is calculated from the aspect ratio of the frame.
+func NewResizeTransform(width, height int, fit Fit) FrameTransform {
+	return FrameTransform{
+		Resize: func(f *Frame) {
+			w, h := width, height
+			if w == 0 {
+				w = int(math.Floor(float64(h*f.Width)/float64(f.Height) + .5))
+			} else if h == 0 {
+				h = int(math.Floor(float64(w*f.Height)/float64(f.Width) + .5))
+			}
+
+			if f.Data != nil {
+				f.Data = fit.apply(f.Data, f.Width, f.Height, w, h)
+			}
+			f.Width = w
+			f.Height = h
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package gomovie_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func solidFrame(width, height int, c color.NRGBA) *gomovie.Frame {
+	data := make([]byte, width*height*4)
+	for i := 0; i < len(data); i += 4 {
+		data[i], data[i+1], data[i+2], data[i+3] = c.R, c.G, c.B, c.A
+	}
+	return &gomovie.Frame{Data: data, Width: width, Height: height}
+}
+
+func TestResizeContain(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+
+	//portrait frame in a landscape canvas should be pillarboxed
+	f := solidFrame(90, 160, red)
+
+	transform := gomovie.NewResizeTransform(320, 180, gomovie.Fit{Mode: gomovie.FitContain, Filter: gomovie.Lanczos, Background: color.White})
+	transform.Resize(f)
+
+	if f.Width != 320 || f.Height != 180 || len(f.Data) != 320*180*4 {
+		t.Fatalf("Unexpected size %vx%v (%v bytes)", f.Width, f.Height, len(f.Data))
+	}
+
+	img := f.ToNRGBAImage()
+
+	if c := img.NRGBAAt(0, 90); c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Fatalf("Expected white pillarbox but got %v", c)
+	}
+
+	if c := img.NRGBAAt(160, 90); c != red {
+		t.Fatalf("Expected red center but got %v", c)
+	}
+}
+
+func TestResizeKeepAspect(t *testing.T) {
+	for _, filter := range []gomovie.ResizeFilter{gomovie.NearestNeighbor, gomovie.Bilinear, gomovie.Bicubic, gomovie.Lanczos} {
+		f := solidFrame(64, 48, color.NRGBA{10, 20, 30, 255})
+
+		gomovie.NewResizeTransform(32, 0, gomovie.Fit{Mode: gomovie.FitStretch, Filter: filter}).Resize(f)
+
+		if f.Width != 32 || f.Height != 24 {
+			t.Fatalf("Unexpected size %vx%v", f.Width, f.Height)
+		}
+
+		if c := f.ToNRGBAImage().NRGBAAt(16, 12); c != (color.NRGBA{10, 20, 30, 255}) {
+			t.Fatalf("Filter %v changed a solid color to %v", filter, c)
+		}
+	}
+}
+
+func TestResizeCover(t *testing.T) {
+	f := solidFrame(100, 100, color.NRGBA{0, 0, 255, 255})
+
+	gomovie.NewResizeTransform(200, 50, gomovie.Fit{Mode: gomovie.FitCover, Background: color.Black}).Resize(f)
+
+	if c := f.ToNRGBAImage().NRGBAAt(0, 0); c != (color.NRGBA{0, 0, 255, 255}) {
+		t.Fatalf("Cover should not leave a border but got %v", c)
+	}
+}
@@ -0,0 +1,80 @@
+package gomovie
+
+import "encoding/binary"
+
+// normalizeRotation converts the degrees to 0, 90, 180 or 270. Returns false when the degrees are not a multiple of 90
+func normalizeRotation(degrees int) (int, bool) {
+	if degrees%90 != 0 {
+		return 0, false
+	}
+	return ((degrees % 360) + 360) % 360, true
+}
+
+// remapRGBA moves each pixel of the w x h src to the position returned by fn in a dw x dh buffer
+func remapRGBA(src []byte, w, h, dw, dh int, fn func(x, y int) (int, int)) []byte {
+	dst := make([]byte, dw*dh*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := fn(x, y)
+			p := binary.LittleEndian.Uint32(src[(y*w+x)*4:])
+			binary.LittleEndian.PutUint32(dst[(dy*dw+dx)*4:], p)
+		}
+	}
+	return dst
+}
+
+// NewRotateTransform creates a FrameTransform which rotates each frame clockwise by the given degrees.
+// Only multiples of 90 are supported, other values will panic. The Width and Height of the frame are swapped for 90 and 270 degrees.
+func NewRotateTransform(degrees int) FrameTransform {
+	rotation, ok := normalizeRotation(degrees)
+	if !ok {
+		panic(invalidRotationError)
+	}
+
+	return FrameTransform{
+		Resize: func(f *Frame) {
+			w, h := f.Width, f.Height
+
+			var fn func(x, y int) (int, int)
+
+			switch rotation {
+			case 0:
+				return
+			case 90:
+				f.Width, f.Height = h, w
+				fn = func(x, y int) (int, int) { return h - 1 - y, x }
+			case 180:
+				fn = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+			case 270:
+				f.Width, f.Height = h, w
+				fn = func(x, y int) (int, int) { return y, w - 1 - x }
+			}
+
+			if f.Data != nil {
+				f.Data = remapRGBA(f.Data, w, h, f.Width, f.Height, fn)
+			}
+		},
+	}
+}
+
+// NewFlipTransform creates a FrameTransform which mirrors each frame horizontally and/or vertically
+func NewFlipTransform(horizontal, vertical bool) FrameTransform {
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			if !horizontal && !vertical {
+				return
+			}
+
+			w, h := f.Width, f.Height
+			f.Data = remapRGBA(f.Data, w, h, w, h, func(x, y int) (int, int) {
+				if horizontal {
+					x = w - 1 - x
+				}
+				if vertical {
+					y = h - 1 - y
+				}
+				return x, y
+			})
+		},
+	}
+}
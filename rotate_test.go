@@ -0,0 +1,52 @@
+package gomovie_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestRotateFrame(t *testing.T) {
+	f := solidFrame(4, 2, color.NRGBA{0, 0, 0, 255})
+	f.ToNRGBAImage().SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 255}) //mark the top left corner
+
+	gomovie.NewRotateTransform(90).Resize(f)
+
+	if f.Width != 2 || f.Height != 4 {
+		t.Fatalf("Expected the size to be swapped but got %vx%v", f.Width, f.Height)
+	}
+
+	//top left ends up in the top right corner after a clockwise rotation
+	if c := f.ToNRGBAImage().NRGBAAt(1, 0); c.R != 255 {
+		t.Fatalf("Expected red pixel at top right but got %v", c)
+	}
+
+	gomovie.NewRotateTransform(-90).Resize(f)
+	gomovie.NewFlipTransform(true, true).Transform(f)
+
+	if c := f.ToNRGBAImage().NRGBAAt(3, 1); c.R != 255 {
+		t.Fatalf("Expected red pixel at bottom right but got %v", c)
+	}
+}
+
+func TestRotateInfo(t *testing.T) {
+	reader := gomovie.NewNullFrameReader(&gomovie.FrameReaderInfo{Width: 1920, Height: 1080, FrameRate: 25, Duration: 1})
+
+	transformer := gomovie.NewFrameTransformer(reader)
+	transformer.AddTransform(gomovie.NewRotateTransform(270))
+
+	info := transformer.Info()
+	if info.Width != 1080 || info.Height != 1920 {
+		t.Fatalf("Info was not updated by the rotation. Got %vx%v", info.Width, info.Height)
+	}
+
+	f, err := transformer.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Width != info.Width || f.Height != info.Height || len(f.Data) != f.Width*f.Height*4 {
+		t.Fatal("Frame size does not match the info")
+	}
+}
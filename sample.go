@@ -1,6 +1,10 @@
 package gomovie
 
-import "io"
+import (
+	"fmt"
+	"io"
+	"math"
+)
 
 //SampleInt16 describes a single 16 bit sample
 type SampleInt16 int16
@@ -10,6 +14,14 @@ func (p SampleInt16) Float() float32 {
 	return float32(p) / float32(32768.)
 }
 
+//SampleInt24 describes a single 24 bit sample. The value is stored in the lower 24 bits.
+type SampleInt24 int32
+
+//ToFloat Normalizes the sample to a value between -1 and 1
+func (p SampleInt24) Float() float32 {
+	return float32(p) / float32(8388608.)
+}
+
 //SampleInt32 describes a single 32 bit sample
 type SampleInt32 int32
 
@@ -18,9 +30,33 @@ func (p SampleInt32) Float() float32 {
 	return float32(p) / float32(2147483648.)
 }
 
+//SampleFloat32 describes a single 32 bit float sample. Normally between -1 and 1 but it may exceed that range.
+type SampleFloat32 float32
+
+//ToFloat returns the sample value
+func (p SampleFloat32) Float() float32 {
+	return float32(p)
+}
+
+//SampleLayout describes the order of the samples of the channels in a SampleBlock
+type SampleLayout int
+
+const (
+	//Interleaved stores the samples of all channels for a moment next to each other (L R L R)
+	Interleaved SampleLayout = iota
+	//Planar stores all the samples of a channel next to each other (L L R R)
+	Planar
+)
+
 //SampleFormat describes the format of a SampleBlock
 type SampleFormat struct {
-	Depth     int
+	//Depth is 16, 24 or 32
+	Depth int
+	//Float uses 32 bit float samples. The Depth is ignored.
+	Float bool
+	//Layout of the Data of a SampleBlock. Bytes are always interleaved.
+	Layout SampleLayout
+	//BlockSize in bytes
 	BlockSize int
 }
 
@@ -29,51 +65,356 @@ func NewSampleFormat() *SampleFormat {
 	return &SampleFormat{Depth: 16, BlockSize: GlobalConfig.SampleBlockSize}
 }
 
+//FfmpegFormat returns the name of the raw ffmpeg format (s16le, s24le, s32le or f32le)
+func (f *SampleFormat) FfmpegFormat() string {
+	if f.Float {
+		return "f32le"
+	}
+	return fmt.Sprintf("s%vle", f.Depth)
+}
+
+//BytesPerSample returns the number of bytes for a single sample of a single channel
+func (f *SampleFormat) BytesPerSample() int {
+	if f.Float {
+		return 4
+	}
+	return f.Depth / 8
+}
+
+//makeSampleData creates zero sample data for the format
+func makeSampleData(format *SampleFormat, n int) interface{} {
+	switch {
+	case format.Float:
+		return make([]SampleFloat32, n)
+	case format.Depth == 24:
+		return make([]SampleInt24, n)
+	case format.Depth == 32:
+		return make([]SampleInt32, n)
+	}
+	return make([]SampleInt16, n)
+}
+
 //SampleBlock describes a chunk of sample values
 type SampleBlock struct {
 	*SampleFormat
 
+	//Data is a []SampleInt16, []SampleInt24, []SampleInt32 or []SampleFloat32 depending on the SampleFormat
 	Data     interface{}
 	Time     float32
 	Duration float32
+
+	//Channels is needed to find the samples of a channel in planar data
+	Channels int
+
+	//set for blocks from a SampleBlockPool. full is the data with the block size of the pool.
+	pool     *SampleBlockPool
+	full     interface{}
+	released bool
+}
+
+//newSampleBlock creates a SampleBlock in the format from interleaved floats
+func newSampleBlock(format *SampleFormat, channels int, frames []float32, time, duration float32) *SampleBlock {
+	sb := &SampleBlock{
+		SampleFormat: format,
+		Data:         makeSampleData(format, len(frames)),
+		Time:         time,
+		Duration:     duration,
+		Channels:     channels,
+	}
+	sb.SetFloats(frames)
+	return sb
+}
+
+//Len returns the number of samples (of all channels) in the block
+func (sb *SampleBlock) Len() int {
+	switch d := sb.Data.(type) {
+	case []SampleInt16:
+		return len(d)
+	case []SampleInt24:
+		return len(d)
+	case []SampleInt32:
+		return len(d)
+	case []SampleFloat32:
+		return len(d)
+	}
+	return 0
+}
+
+//Int16 returns the data when it contains 16 bit samples. Otherwise nil.
+func (sb *SampleBlock) Int16() []SampleInt16 {
+	d, _ := sb.Data.([]SampleInt16)
+	return d
+}
+
+//Int24 returns the data when it contains 24 bit samples. Otherwise nil.
+func (sb *SampleBlock) Int24() []SampleInt24 {
+	d, _ := sb.Data.([]SampleInt24)
+	return d
+}
+
+//Int32 returns the data when it contains 32 bit samples. Otherwise nil.
+func (sb *SampleBlock) Int32() []SampleInt32 {
+	d, _ := sb.Data.([]SampleInt32)
+	return d
+}
+
+//Float32 returns the data when it contains float samples. Otherwise nil.
+func (sb *SampleBlock) Float32() []SampleFloat32 {
+	d, _ := sb.Data.([]SampleFloat32)
+	return d
+}
+
+//isPlanar is true when the data needs reordering to be interleaved
+func (sb *SampleBlock) isPlanar() bool {
+	return sb.SampleFormat != nil && sb.Layout == Planar && sb.Channels > 1
+}
+
+//interleavedIndex returns the index in the data of the i-th interleaved sample
+func (sb *SampleBlock) interleavedIndex(i, n int) int {
+	if !sb.isPlanar() {
+		return i
+	}
+	frames := n / sb.Channels
+	return (i%sb.Channels)*frames + i/sb.Channels
+}
+
+//Floats returns the samples as interleaved floats. Float samples keep their value, integers are normalized between -1 and 1.
+func (sb *SampleBlock) Floats() []float32 {
+	n := sb.Len()
+	out := make([]float32, n)
+
+	switch d := sb.Data.(type) {
+	case []SampleInt16:
+		for i := range out {
+			out[i] = d[sb.interleavedIndex(i, n)].Float()
+		}
+	case []SampleInt24:
+		for i := range out {
+			out[i] = d[sb.interleavedIndex(i, n)].Float()
+		}
+	case []SampleInt32:
+		for i := range out {
+			out[i] = d[sb.interleavedIndex(i, n)].Float()
+		}
+	case []SampleFloat32:
+		for i := range out {
+			out[i] = float32(d[sb.interleavedIndex(i, n)])
+		}
+	}
+
+	return out
+}
+
+//SetFloats replaces the samples with the interleaved floats. The length of the data should be the same.
+//Integer samples are rounded and clipped, float samples are stored as is.
+func (sb *SampleBlock) SetFloats(f []float32) {
+	n := sb.Len()
+
+	switch d := sb.Data.(type) {
+	case []SampleInt16:
+		for i, v := range f {
+			d[sb.interleavedIndex(i, n)] = SampleInt16(floatToInt(float64(v), 32768))
+		}
+	case []SampleInt24:
+		for i, v := range f {
+			d[sb.interleavedIndex(i, n)] = SampleInt24(floatToInt(float64(v), 8388608))
+		}
+	case []SampleInt32:
+		for i, v := range f {
+			d[sb.interleavedIndex(i, n)] = SampleInt32(floatToInt(float64(v), 2147483648))
+		}
+	case []SampleFloat32:
+		for i, v := range f {
+			d[sb.interleavedIndex(i, n)] = SampleFloat32(v)
+		}
+	}
 }
 
-func (sb *SampleBlock) Bytes() (bd []byte) {
+//ConvertTo returns a copy of the block in another format. Converting to a format with the same or more precision
+//(16 to 24, 32 or float and 24 to 32 or float) is lossless.
+func (sb *SampleBlock) ConvertTo(format *SampleFormat) *SampleBlock {
+	n := sb.Len()
+	values := make([]float64, n)
+
+	//use float64 so 32 bit integers don't lose precision
 	switch d := sb.Data.(type) {
 	case []SampleInt16:
-		bd = make([]byte, len(d)*2)
-		for i, x := range d {
-			v := uint16(x)
-			bd[i] = byte(v)
-			bd[i+1] = byte(v >> 8)
+		for i := range values {
+			values[i] = float64(d[sb.interleavedIndex(i, n)]) / 32768
+		}
+	case []SampleInt24:
+		for i := range values {
+			values[i] = float64(d[sb.interleavedIndex(i, n)]) / 8388608
 		}
 	case []SampleInt32:
-		bd = make([]byte, len(d)*4)
-		for i, x := range d {
-			v := uint32(x)
-			bd[i] = byte(v)
-			bd[i+1] = byte(v >> 8)
-			bd[i+2] = byte(v >> 16)
-			bd[i+3] = byte(v >> 24)
+		for i := range values {
+			values[i] = float64(d[sb.interleavedIndex(i, n)]) / 2147483648
 		}
+	case []SampleFloat32:
+		for i := range values {
+			values[i] = float64(d[sb.interleavedIndex(i, n)])
+		}
+	}
+
+	out := &SampleBlock{
+		SampleFormat: format,
+		Data:         makeSampleData(format, n),
+		Time:         sb.Time,
+		Duration:     sb.Duration,
+		Channels:     sb.Channels,
 	}
-	return
+
+	switch d := out.Data.(type) {
+	case []SampleInt16:
+		for i, v := range values {
+			d[out.interleavedIndex(i, n)] = SampleInt16(floatToInt(v, 32768))
+		}
+	case []SampleInt24:
+		for i, v := range values {
+			d[out.interleavedIndex(i, n)] = SampleInt24(floatToInt(v, 8388608))
+		}
+	case []SampleInt32:
+		for i, v := range values {
+			d[out.interleavedIndex(i, n)] = SampleInt32(floatToInt(v, 2147483648))
+		}
+	case []SampleFloat32:
+		for i, v := range values {
+			d[out.interleavedIndex(i, n)] = SampleFloat32(v)
+		}
+	}
+
+	return out
+}
+
+//Bytes returns the samples as interleaved little endian bytes. 24 bit samples are packed in 3 bytes.
+func (sb *SampleBlock) Bytes() []byte {
+	return sb.AppendBytes(nil)
 }
 
-//ConvertFloats converts each value to a float (normalized between 0 and 1) and passes it to the given callback. The callback is expected to return a modified float value.
+//AppendBytes appends the samples as interleaved little endian bytes to dst and returns the extended slice.
+//Pass a buffer from a previous call to convert blocks without allocating.
+func (sb *SampleBlock) AppendBytes(dst []byte) []byte {
+	n := len(dst)
+	size := sb.Len() * sb.BytesPerSample()
+
+	if cap(dst)-n < size {
+		grown := make([]byte, n, n+size)
+		copy(grown, dst)
+		dst = grown
+	}
+
+	dst = dst[:n+size]
+	sb.putBytes(dst[n:])
+	return dst
+}
+
+//putBytes writes the samples as interleaved little endian bytes to bd which should have room for all the samples
+func (sb *SampleBlock) putBytes(bd []byte) {
+	n := sb.Len()
+
+	switch d := sb.Data.(type) {
+	case []SampleInt16:
+		for i := 0; i < n; i++ {
+			v := uint16(d[sb.interleavedIndex(i, n)])
+			bd[i*2] = byte(v)
+			bd[i*2+1] = byte(v >> 8)
+		}
+	case []SampleInt24:
+		for i := 0; i < n; i++ {
+			v := uint32(d[sb.interleavedIndex(i, n)])
+			bd[i*3] = byte(v)
+			bd[i*3+1] = byte(v >> 8)
+			bd[i*3+2] = byte(v >> 16)
+		}
+	case []SampleInt32:
+		for i := 0; i < n; i++ {
+			putUint32LE(bd[i*4:], uint32(d[sb.interleavedIndex(i, n)]))
+		}
+	case []SampleFloat32:
+		for i := 0; i < n; i++ {
+			putUint32LE(bd[i*4:], math.Float32bits(float32(d[sb.interleavedIndex(i, n)])))
+		}
+	}
+}
+
+func putUint32LE(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+	b[3] = byte(v >> 24)
+}
+
+//parseSampleBytes converts interleaved little endian bytes in the format to sample data in the layout of the format
+func parseSampleBytes(b []byte, format *SampleFormat, channels int) *SampleBlock {
+	sb := &SampleBlock{SampleFormat: format, Data: makeSampleData(format, len(b)/format.BytesPerSample()), Channels: channels}
+	sb.decodeBytes(b)
+	return sb
+}
+
+//decodeBytes converts interleaved little endian bytes to the existing sample data. The data should have room for all the samples.
+func (sb *SampleBlock) decodeBytes(b []byte) {
+	n := len(b) / sb.BytesPerSample()
+
+	switch d := sb.Data.(type) {
+	case []SampleInt16:
+		for i := 0; i < n; i++ {
+			d[sb.interleavedIndex(i, n)] = SampleInt16(uint16(b[i*2]) | uint16(b[i*2+1])<<8)
+		}
+	case []SampleInt24:
+		for i := 0; i < n; i++ {
+			v := uint32(b[i*3]) | uint32(b[i*3+1])<<8 | uint32(b[i*3+2])<<16
+			d[sb.interleavedIndex(i, n)] = SampleInt24(int32(v<<8) >> 8) //sign extend
+		}
+	case []SampleInt32:
+		for i := 0; i < n; i++ {
+			d[sb.interleavedIndex(i, n)] = SampleInt32(uint32LE(b[i*4:]))
+		}
+	case []SampleFloat32:
+		for i := 0; i < n; i++ {
+			d[sb.interleavedIndex(i, n)] = SampleFloat32(math.Float32frombits(uint32LE(b[i*4:])))
+		}
+	}
+}
+
+func uint32LE(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+//ConvertFloats converts each value to a float (normalized between -1 and 1) and passes it to the given callback. The callback is expected to return a modified float value.
+//The index is the index in Data. Float samples are not quantized so repeated calls don't lose precision.
 func (sb *SampleBlock) ConvertFloats(fn func(i int, f float32) float32) {
 	switch t := sb.Data.(type) {
 	case []SampleInt16:
 		for i, v := range t {
-			t[i] = SampleInt16(fn(i, v.Float()) * 32768.)
+			t[i] = SampleInt16(floatToInt(float64(fn(i, v.Float())), 32768))
+		}
+	case []SampleInt24:
+		for i, v := range t {
+			t[i] = SampleInt24(floatToInt(float64(fn(i, v.Float())), 8388608))
 		}
 	case []SampleInt32:
 		for i, v := range t {
-			t[i] = SampleInt32(fn(i, v.Float()) * 2147483648.)
+			t[i] = SampleInt32(floatToInt(float64(fn(i, v.Float())), 2147483648))
+		}
+	case []SampleFloat32:
+		for i, v := range t {
+			t[i] = SampleFloat32(fn(i, float32(v)))
 		}
 	}
 }
 
+//floatToInt scales the float to an integer sample with the given full scale. Values out of range are clipped to prevent wrap arounds.
+func floatToInt(v float64, scale float64) int64 {
+	v = math.Floor(v*scale + .5)
+	if v < -scale {
+		return int64(-scale)
+	}
+	if v > scale-1 {
+		return int64(scale - 1)
+	}
+	return int64(v)
+}
+
 //SampleReaderInfo contains information about an Audio stream in a video file
 type SampleReaderInfo struct {
 	CodecName  string
@@ -89,7 +430,7 @@ type SampleReader interface {
 	Slice(r *Range) SampleReader
 	Range() *Range
 
-	//read a single sample block in the format SampleInt16 or SampleInt32 (depending on SampleDepth)
+	//read a single sample block in the format SampleInt16, SampleInt24, SampleInt32 or SampleFloat32 (depending on the SampleFormat)
 	ReadSampleBlock() (*SampleBlock, error)
 
 	SampleFormat() *SampleFormat
@@ -0,0 +1,112 @@
+package gomovie_test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestSampleConvertLossless(t *testing.T) {
+	sb := &gomovie.SampleBlock{
+		SampleFormat: gomovie.NewSampleFormat(),
+		Data:         []gomovie.SampleInt16{-32768, -1, 0, 1, 12345, 32767},
+		Channels:     2,
+	}
+
+	formats := []*gomovie.SampleFormat{
+		{Depth: 24},
+		{Depth: 32},
+		{Depth: 32, Float: true},
+		{Depth: 24, Layout: gomovie.Planar},
+		{Depth: 16},
+	}
+
+	c := sb
+	for _, f := range formats {
+		c = c.ConvertTo(f)
+	}
+
+	for i, v := range c.Int16() {
+		if v != sb.Int16()[i] {
+			t.Fatalf("Expected %v at %v but got %v", sb.Int16()[i], i, v)
+		}
+	}
+}
+
+func TestSampleInt24Bytes(t *testing.T) {
+	sb := &gomovie.SampleBlock{
+		SampleFormat: &gomovie.SampleFormat{Depth: 24},
+		Data:         []gomovie.SampleInt24{-1, 8388607},
+	}
+
+	b := sb.Bytes()
+	if len(b) != 6 {
+		t.Fatalf("Expected 6 bytes but got %v", len(b))
+	}
+
+	if b[0] != 0xff || b[1] != 0xff || b[2] != 0xff || b[3] != 0xff || b[4] != 0xff || b[5] != 0x7f {
+		t.Fatalf("Unexpected bytes %v", b)
+	}
+}
+
+func TestSampleFloatWithoutDepth(t *testing.T) {
+	sb := &gomovie.SampleBlock{
+		SampleFormat: &gomovie.SampleFormat{Float: true},
+		Data:         []gomovie.SampleFloat32{.5, -.5},
+	}
+
+	if n := sb.BytesPerSample(); n != 4 {
+		t.Fatalf("Expected 4 bytes per sample but got %v", n)
+	}
+
+	b := sb.Bytes()
+	if len(b) != 8 {
+		t.Fatalf("Expected 8 bytes but got %v", len(b))
+	}
+	if v := math.Float32frombits(binary.LittleEndian.Uint32(b[4:])); v != -.5 {
+		t.Fatalf("Expected -.5 but got %v", v)
+	}
+}
+
+func TestSamplePlanar(t *testing.T) {
+	sb := &gomovie.SampleBlock{
+		SampleFormat: &gomovie.SampleFormat{Depth: 32, Float: true, Layout: gomovie.Planar},
+		Data:         []gomovie.SampleFloat32{.1, .2, .3, -.1, -.2, -.3},
+		Channels:     2,
+	}
+
+	expected := []float32{.1, -.1, .2, -.2, .3, -.3}
+	for i, v := range sb.Floats() {
+		if v != expected[i] {
+			t.Fatalf("Expected %v at %v but got %v", expected[i], i, v)
+		}
+	}
+
+	b := sb.Bytes()
+	if v := math.Float32frombits(binary.LittleEndian.Uint32(b[4:])); v != -.1 {
+		t.Fatalf("Expected the bytes to be interleaved but got %v", v)
+	}
+}
+
+func TestFloatToneReader(t *testing.T) {
+	reader := gomovie.NewToneReader(gomovie.Square, 100, 1.5, &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: .1})
+	reader.SampleFormat().Depth = 32
+	reader.SampleFormat().Float = true
+
+	//float samples are not clipped
+	if s := readFloats(t, reader); s[0] != 1.5 {
+		t.Fatalf("Expected 1.5 but got %v", s[0])
+	}
+}
+
+func TestNullSampleReaderFormat(t *testing.T) {
+	reader := gomovie.NewNullSampleReader(&gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 2, Duration: 1})
+	reader.SampleFormat().Depth = 24
+
+	samples := readFloats(t, reader)
+	if len(samples) != 16000 {
+		t.Fatalf("Expected 16000 samples but got %v", len(samples))
+	}
+}
@@ -0,0 +1,176 @@
+package gomovie
+
+import (
+	"io"
+	"math"
+)
+
+// SceneDetector finds the shot boundaries (hard cuts) of a FrameReader. Each frame is compared with the previous frame on the
+// color histogram and the content of a downscaled copy. A frame is a cut when the difference is above the threshold and
+// stands out from the differences of the frames before it, so fast motion doesn't trigger cuts.
+type SceneDetector struct {
+	// Threshold is the minimum difference (0 - 1) for a cut
+	Threshold float64
+
+	// AdaptiveRatio is how many times the difference should exceed the mean difference of the previous Window frames
+	AdaptiveRatio float64
+	Window        int
+
+	// MinShotLength in seconds. Cuts within this time of the previous cut are ignored.
+	MinShotLength float32
+
+	// AnalysisWidth is the width of the downscaled frames which are compared
+	AnalysisWidth int
+}
+
+// NewSceneDetector creates a SceneDetector with a threshold of .15, an adaptive ratio of 3 over 8 frames and shots of at least half a second
+func NewSceneDetector() *SceneDetector {
+	return &SceneDetector{
+		Threshold:     .15,
+		AdaptiveRatio: 3,
+		Window:        8,
+		MinShotLength: .5,
+		AnalysisWidth: 64,
+	}
+}
+
+// number of bins of each color channel in the histogram
+const sceneHistogramBins = 16
+
+type sceneFrame struct {
+	pix       []byte
+	histogram [3 * sceneHistogramBins]float64
+}
+
+func (d *SceneDetector) analyze(f *Frame) *sceneFrame {
+	w := intMin(intMax(d.AnalysisWidth, 1), f.Width)
+	h := intMax(f.Height*w/intMax(f.Width, 1), 1)
+
+	s := &sceneFrame{pix: resizeRGBA(f.Data, f.Width, f.Height, w, h, Bilinear)}
+
+	pixels := float64(w * h)
+	for i := 0; i+4 <= len(s.pix); i += 4 {
+		for c := 0; c < 3; c++ {
+			s.histogram[c*sceneHistogramBins+int(s.pix[i+c])*sceneHistogramBins/256] += 1 / pixels
+		}
+	}
+
+	return s
+}
+
+// difference returns a value between 0 (same) and 1 (completely different)
+func (s *sceneFrame) difference(o *sceneFrame) float64 {
+	//earth mover's distance of each channel so a small change of the colors (like a fade) gives a small difference
+	var hist float64
+	for c := 0; c < 3; c++ {
+		var cdf float64
+		for b := 0; b < sceneHistogramBins; b++ {
+			i := c*sceneHistogramBins + b
+			cdf += s.histogram[i] - o.histogram[i]
+			hist += math.Abs(cdf)
+		}
+	}
+	hist /= 3 * (sceneHistogramBins - 1)
+
+	content := 1.
+	if len(s.pix) == len(o.pix) {
+		content = frameDifference(s.pix, o.pix) / 255
+	}
+
+	return (hist + content) / 2
+}
+
+// Detect reads all the frames and returns the shots and the confidence (.5 - 1) of the cut at the start of each shot.
+// The first shot has a confidence of 1. The ranges are relative to the start of the reader so they can be passed to Slice.
+func (d *SceneDetector) Detect(reader FrameReader) (shots []Range, confidence []float64, err error) {
+	frameDuration := 1 / reader.Info().FrameRate
+
+	var (
+		prev    *sceneFrame
+		history []float64
+		start   float32
+		end     float32
+		first   = true
+	)
+
+	for {
+		var f *Frame
+		if f, err = reader.ReadFrame(); err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cur := d.analyze(f)
+		end = f.Time + frameDuration
+
+		if first {
+			start, first = f.Time, false
+			confidence = append(confidence, 1)
+		} else {
+			score := cur.difference(prev)
+
+			var mean float64
+			for _, v := range history {
+				mean += v
+			}
+			if len(history) > 0 {
+				mean /= float64(len(history))
+			}
+
+			effective := math.Max(d.Threshold, d.AdaptiveRatio*mean)
+
+			if score >= effective && f.Time-start >= d.MinShotLength {
+				shots = append(shots, Range{Start: start, Duration: f.Time - start})
+				confidence = append(confidence, .5+.5*(score-effective)/math.Max(1-effective, 1e-6))
+				start = f.Time
+
+				//the cut would raise the mean of the next frames
+				history = history[:0]
+			} else {
+				history = append(history, score)
+				if len(history) > d.Window {
+					history = history[1:]
+				}
+			}
+		}
+
+		prev = cur
+	}
+
+	if !first {
+		shots = append(shots, Range{Start: start, Duration: end - start})
+	}
+
+	return
+}
+
+// SplitByScenes returns a Video for each shot of the video. The frames are scanned with a Slice so the video can still be read.
+// Uses NewSceneDetector when the detector is nil.
+func SplitByScenes(v *Video, detector *SceneDetector) ([]*Video, error) {
+	if v.FrameReader == nil {
+		return []*Video{v}, nil
+	}
+
+	if detector == nil {
+		detector = NewSceneDetector()
+	}
+
+	scan := v.FrameReader.Slice(&Range{Start: 0, Duration: frameReaderDuration(v.FrameReader)})
+	shots, _, err := detector.Detect(scan)
+	scan.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	videos := make([]*Video, len(shots))
+	for i := range shots {
+		if videos[i], err = v.Slice(&shots[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return videos, nil
+}
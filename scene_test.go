@@ -0,0 +1,65 @@
+package gomovie_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestSceneDetector(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 64, Height: 36, FrameRate: 25, Duration: 1}
+	reader := gomovie.Concat(
+		gomovie.NewTestPatternClip(info),
+		colorClip(color.NRGBA{200, 0, 0, 255}, 1),
+		gomovie.NewCheckerboardClip(4, color.Black, color.White, info),
+	).FrameReader
+
+	shots, confidence, err := gomovie.NewSceneDetector().Detect(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(shots) != 3 {
+		t.Fatalf("Expected 3 shots but got %v", shots)
+	}
+
+	for i, s := range shots {
+		if !near(s.Start, float32(i)) || !near(s.Duration, 1) {
+			t.Fatalf("Unexpected shot %v", s)
+		}
+		if confidence[i] < .5 || confidence[i] > 1 {
+			t.Fatalf("Unexpected confidence %v", confidence[i])
+		}
+	}
+}
+
+func TestSceneDetectorIgnoresFades(t *testing.T) {
+	faded := gomovie.FadeFrames(colorClip(color.White, 3), 1.5, 1.5, gomovie.FadeLinear, color.Black)
+
+	shots, _, err := gomovie.NewSceneDetector().Detect(faded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(shots) != 1 {
+		t.Fatalf("Expected a single shot but got %v", shots)
+	}
+}
+
+func TestSplitByScenes(t *testing.T) {
+	v := gomovie.Concat(colorClip(color.White, 1), colorClip(color.Black, 2))
+
+	videos, err := gomovie.SplitByScenes(v, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(videos) != 2 {
+		t.Fatalf("Expected 2 videos but got %v", len(videos))
+	}
+
+	if r := videos[1].FrameReader.Range(); !near(r.Start, 1) || !near(r.Duration, 2) {
+		t.Fatalf("Unexpected range %v", r)
+	}
+}
@@ -0,0 +1,283 @@
+package gomovie
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"math/cmplx"
+)
+
+var invalidSpectrogramRangeError = errors.New("Spectrogram range should be above 0")
+
+// WindowFunction is applied to the samples of each transform of a spectrogram to reduce spectral leakage
+type WindowFunction int
+
+const (
+	Hann WindowFunction = iota
+	Hamming
+	Blackman
+	// Rectangular applies no window. Gives the sharpest peaks but the most leakage.
+	Rectangular
+)
+
+// coefficients returns the window of n samples
+func (w WindowFunction) coefficients(n int) []float64 {
+	c := make([]float64, n)
+	for i := range c {
+		p := 2 * math.Pi * float64(i) / float64(n-1)
+		switch w {
+		case Hann:
+			c[i] = .5 - .5*math.Cos(p)
+		case Hamming:
+			c[i] = .54 - .46*math.Cos(p)
+		case Blackman:
+			c[i] = .42 - .5*math.Cos(p) + .08*math.Cos(2*p)
+		default:
+			c[i] = 1
+		}
+	}
+	return c
+}
+
+// fft transforms x in place. The length of x should be a power of 2.
+func fft(x []complex128) {
+	n := len(x)
+
+	//bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*w
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
+
+// SpectrogramStyle describes how a spectrogram is drawn
+type SpectrogramStyle struct {
+	Width, Height int
+
+	// FFTSize is the number of samples of each transform. It is rounded up to a power of 2.
+	FFTSize int
+	Window  WindowFunction
+
+	// LogFrequency uses a logarithmic frequency axis from MinFrequency to half the sample rate. Otherwise the axis is linear from 0.
+	LogFrequency bool
+	MinFrequency float64
+
+	// Range in dB below full scale which is shown. Quieter frequencies are black.
+	Range float64
+}
+
+// DefaultSpectrogramStyle is a 1280x360 spectrogram with a Hann window of 2048 samples, a logarithmic frequency axis from 20 Hz and a range of 100 dB
+var DefaultSpectrogramStyle = SpectrogramStyle{
+	Width:        1280,
+	Height:       360,
+	FFTSize:      2048,
+	Window:       Hann,
+	LogFrequency: true,
+	MinFrequency: 20,
+	Range:        100,
+}
+
+// colors of the spectrogram from silent to full scale
+var spectrogramPalette = []color.NRGBA{
+	{0, 0, 0, 255},
+	{40, 0, 90, 255},
+	{180, 0, 90, 255},
+	{255, 120, 0, 255},
+	{255, 220, 60, 255},
+	{255, 255, 230, 255},
+}
+
+// spectrogramColor returns the color of a level between 0 and 1
+func spectrogramColor(p float64) color.NRGBA {
+	p = math.Max(math.Min(p, 1), 0) * float64(len(spectrogramPalette)-1)
+	i := intMin(int(p), len(spectrogramPalette)-2)
+	f := p - float64(i)
+
+	a, b := spectrogramPalette[i], spectrogramPalette[i+1]
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*f + .5) }
+
+	return color.NRGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+// frequencyBins returns the first and last bin of each row from top (highest frequency) to bottom
+func (style *SpectrogramStyle) frequencyBins(rate, size int) [][2]int {
+	nyquist := float64(rate) / 2
+
+	freq := func(y float64) float64 {
+		p := 1 - y/float64(style.Height)
+		if style.LogFrequency {
+			min := math.Max(style.MinFrequency, 1)
+			return min * math.Pow(nyquist/min, p)
+		}
+		return nyquist * p
+	}
+
+	bins := make([][2]int, style.Height)
+	for y := range bins {
+		lo := int(math.Floor(freq(float64(y+1))*float64(size)/float64(rate) + .5))
+		hi := int(math.Floor(freq(float64(y))*float64(size)/float64(rate) + .5))
+
+		lo = intMin(intMax(lo, 0), size/2)
+		hi = intMin(intMax(hi, lo), size/2)
+		bins[y] = [2]int{lo, hi}
+	}
+	return bins
+}
+
+// RenderSpectrogram reads the reader and draws the spectrum of the channels mixed to mono over time. Each column is the
+// transform of FFTSize samples around the time of the column. The samples are transformed while reading.
+func RenderSpectrogram(reader SampleReader, style SpectrogramStyle) (*image.NRGBA, error) {
+	if style.Range <= 0 {
+		return nil, invalidSpectrogramRangeError
+	}
+
+	info := reader.Info()
+	img := image.NewNRGBA(image.Rect(0, 0, style.Width, style.Height))
+
+	size := 1
+	for size < style.FFTSize || size < 2 {
+		size <<= 1
+	}
+
+	window := style.Window.coefficients(size)
+	var windowSum float64
+	for _, v := range window {
+		windowSum += v
+	}
+
+	bins := style.frequencyBins(info.SampleRate, size)
+
+	frames := float64(sampleReaderDuration(reader)) * float64(info.SampleRate)
+	hop := frames / float64(intMax(style.Width, 1))
+
+	//first sample of the transform of a column
+	columnStart := func(x int) int {
+		return int(math.Floor(hop*(float64(x)+.5))) - size/2
+	}
+
+	var (
+		buf  []float64
+		base int
+		x    int
+	)
+
+	spectrum := make([]complex128, size)
+	magnitude := make([]float64, size/2+1)
+
+	column := func() {
+		start := columnStart(x)
+		for i := range spectrum {
+			var v float64
+			if j := start + i - base; j >= 0 && j < len(buf) {
+				v = buf[j]
+			}
+			spectrum[i] = complex(v*window[i], 0)
+		}
+
+		fft(spectrum)
+
+		//scaled so a full scale sine is 0 dB
+		for k := range magnitude {
+			magnitude[k] = cmplx.Abs(spectrum[k]) * 2 / windowSum
+		}
+
+		for y, b := range bins {
+			var peak float64
+			for k := b[0]; k <= b[1]; k++ {
+				peak = math.Max(peak, magnitude[k])
+			}
+
+			db := 20 * math.Log10(math.Max(peak, 1e-12))
+			o := (y*style.Width + x) * 4
+			c := spectrogramColor(1 + db/style.Range)
+			img.Pix[o], img.Pix[o+1], img.Pix[o+2], img.Pix[o+3] = c.R, c.G, c.B, c.A
+		}
+
+		x++
+
+		//drop the samples which the next columns don't need
+		if x < style.Width {
+			if drop := columnStart(x) - base; drop > 0 {
+				drop = intMin(drop, len(buf))
+				buf = append(buf[:0], buf[drop:]...)
+				base += drop
+			}
+		}
+	}
+
+	input := newFloatFrameReader(reader)
+	for x < style.Width {
+		samples, err := input.read(4096)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i+info.Channels <= len(samples); i += info.Channels {
+			var sum float64
+			for _, v := range samples[i : i+info.Channels] {
+				sum += float64(v)
+			}
+			buf = append(buf, sum/float64(info.Channels))
+		}
+
+		for x < style.Width && base+len(buf) >= columnStart(x)+size {
+			column()
+		}
+	}
+
+	//the last columns are padded with silence
+	for x < style.Width {
+		column()
+	}
+
+	return img, nil
+}
+
+// WriteSpectrogramPNG reads the reader and writes its spectrogram as a PNG
+func WriteSpectrogramPNG(w io.Writer, reader SampleReader, style SpectrogramStyle) error {
+	img, err := RenderSpectrogram(reader, style)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// NewSpectrogramClip creates a FrameReader for audiograms which shows the spectrogram of the reader with a white playhead.
+// The spectrogram is rendered from a Slice so the reader can still be used for the audio of the video.
+// When the Width or Height of info is 0 the size of the style is used, when the Duration is 0 the duration of the reader.
+func NewSpectrogramClip(reader SampleReader, style SpectrogramStyle, info *FrameReaderInfo) (FrameReader, error) {
+	duration := sampleReaderDuration(reader)
+
+	scan := reader.Slice(&Range{Start: 0, Duration: duration})
+	img, err := RenderSpectrogram(scan, style)
+	scan.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return newPlayheadClip(img, color.White, duration, info), nil
+}
@@ -0,0 +1,66 @@
+package gomovie_test
+
+import (
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestRenderSpectrogram(t *testing.T) {
+	style := gomovie.SpectrogramStyle{Width: 20, Height: 64, FFTSize: 1024, Window: gomovie.Hann, Range: 80}
+	reader := gomovie.NewToneReader(gomovie.Sine, 2000, 1, &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1})
+
+	img, err := gomovie.RenderSpectrogram(reader, style)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//2000 Hz is half of the linear axis up to 4000 Hz
+	brightest, row := 0, -1
+	for y := 0; y < style.Height; y++ {
+		c := img.NRGBAAt(10, y)
+		if v := int(c.R) + int(c.G) + int(c.B); v > brightest {
+			brightest, row = v, y
+		}
+	}
+
+	if row < 30 || row > 33 {
+		t.Fatalf("Expected the tone in the middle row but got %v", row)
+	}
+
+	if c := img.NRGBAAt(10, 5); c.R+c.G+c.B != 0 {
+		t.Fatalf("Expected no energy at high frequencies but got %v", c)
+	}
+}
+
+func TestSpectrogramLogFrequency(t *testing.T) {
+	style := gomovie.SpectrogramStyle{Width: 10, Height: 100, FFTSize: 4096, Window: gomovie.Blackman, LogFrequency: true, MinFrequency: 40, Range: 80}
+	reader := gomovie.NewToneReader(gomovie.Sine, 400, 1, &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1})
+
+	img, err := gomovie.RenderSpectrogram(reader, style)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//400 Hz is a decade above 40 Hz, half way to 4000 Hz on a log axis
+	brightest, row := 0, -1
+	for y := 0; y < style.Height; y++ {
+		c := img.NRGBAAt(5, y)
+		if v := int(c.R) + int(c.G) + int(c.B); v > brightest {
+			brightest, row = v, y
+		}
+	}
+
+	if row < 48 || row > 52 {
+		t.Fatalf("Expected the tone in the middle row but got %v", row)
+	}
+}
+
+func TestRenderSpectrogramRange(t *testing.T) {
+	style := gomovie.SpectrogramStyle{Width: 20, Height: 64, FFTSize: 1024}
+	reader := gomovie.NewToneReader(gomovie.Sine, 2000, 1, &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1})
+
+	if _, err := gomovie.RenderSpectrogram(reader, style); err == nil {
+		t.Fatal("Expected an error for a range of 0")
+	}
+}
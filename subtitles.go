@@ -0,0 +1,303 @@
+package gomovie
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+)
+
+// Cue describes a single subtitle which is shown from Start until End (in seconds)
+type Cue struct {
+	Start float32
+	End   float32
+	Text  string
+
+	// Style is the name of the SubtitleStyle (only used by ASS)
+	Style string
+}
+
+// SubtitleStyle describes the basic ASS style properties
+type SubtitleStyle struct {
+	Name     string
+	FontName string
+	FontSize float64
+	Bold     bool
+	Italic   bool
+
+	PrimaryColor color.NRGBA
+	OutlineColor color.NRGBA
+	BackColor    color.NRGBA
+
+	Outline float64
+	Shadow  float64
+
+	// Alignment uses the numpad layout. 1-3 is bottom, 4-6 is middle and 7-9 is top.
+	Alignment int
+
+	MarginL int
+	MarginR int
+	MarginV int
+}
+
+// Subtitles contains the cues (sorted by start time) and optional styles
+type Subtitles struct {
+	Cues   []Cue
+	Styles []SubtitleStyle
+}
+
+// style returns the style with the name or nil
+func (s *Subtitles) style(name string) *SubtitleStyle {
+	for i := range s.Styles {
+		if s.Styles[i].Name == name {
+			return &s.Styles[i]
+		}
+	}
+	return nil
+}
+
+// Slice returns the cues which are visible within the range. The times are made relative to the start of the range
+// so they line up with the frames of a sliced FrameReader.
+func (s *Subtitles) Slice(r *Range) *Subtitles {
+	end := r.Start + r.Duration
+
+	out := &Subtitles{Styles: s.Styles}
+
+	for _, cue := range s.Cues {
+		if cue.End <= r.Start || cue.Start >= end {
+			continue
+		}
+
+		cue.Start = float32(math.Max(float64(cue.Start), float64(r.Start))) - r.Start
+		cue.End = float32(math.Min(float64(cue.End), float64(end))) - r.Start
+
+		out.Cues = append(out.Cues, cue)
+	}
+
+	return out
+}
+
+// concatSubtitles joins the subtitles. Each subtitle is shifted by the sum of the preceding durations. Nil subtitles are skipped.
+func concatSubtitles(subs []*Subtitles, durations []float32) *Subtitles {
+	out := new(Subtitles)
+
+	var offset float32
+
+	for i, s := range subs {
+		if s != nil {
+			for _, cue := range s.Cues {
+				cue.Start += offset
+				cue.End += offset
+				out.Cues = append(out.Cues, cue)
+			}
+
+			for _, style := range s.Styles {
+				if out.style(style.Name) == nil {
+					out.Styles = append(out.Styles, style)
+				}
+			}
+		}
+
+		offset += durations[i]
+	}
+
+	return out
+}
+
+// NewSubtitleTransform creates a FrameTransform which burns the cues which are active at the time of the frame into the frame.
+// The style is the default for all cues. The basic properties of ASS styles (font size, bold, italic, colors, outline, alignment and margins)
+// override it. The sizes and margins of ASS styles are in pixels of the frame. The font size scales the rendered text.
+func NewSubtitleTransform(subs *Subtitles, style TextStyle) FrameTransform {
+	type rendered struct {
+		img   *image.NRGBA
+		align int
+		ml    int
+		mr    int
+		mv    int
+	}
+
+	//the wrapping and margins depend on the width of the frame
+	type cacheKey struct {
+		index, width int
+	}
+
+	var (
+		mu    sync.Mutex
+		cache = make(map[cacheKey]*rendered)
+	)
+
+	render := func(index int, frameWidth int) *rendered {
+		mu.Lock()
+		defer mu.Unlock()
+
+		key := cacheKey{index, frameWidth}
+		if r, ok := cache[key]; ok {
+			return r
+		}
+
+		cue := subs.Cues[index]
+
+		cueStyle := style
+		cueStyle.Align = AlignCenter
+
+		margin := frameWidth / 20
+		r := &rendered{align: 2, ml: margin, mr: margin, mv: margin}
+
+		s := subs.style(cue.Style)
+
+		//the text is rendered at the size of the face and scaled to the font size afterwards
+		scale := 1.
+		if s != nil && s.FontSize > 0 {
+			scale = s.FontSize / float64(cueStyle.face().Metrics().Height.Ceil())
+		}
+
+		if s != nil {
+			cueStyle.Color = s.PrimaryColor
+			cueStyle.OutlineColor = s.OutlineColor
+			cueStyle.OutlineWidth = int(math.Ceil(s.Outline / scale))
+
+			if s.Shadow > 0 {
+				offset := int(math.Ceil(s.Shadow / scale))
+				cueStyle.ShadowColor = s.BackColor
+				cueStyle.ShadowOffset = image.Pt(offset, offset)
+			}
+
+			if s.Alignment >= 1 && s.Alignment <= 9 {
+				r.align = s.Alignment
+			}
+
+			switch r.align % 3 {
+			case 1:
+				cueStyle.Align = AlignLeft
+			case 0:
+				cueStyle.Align = AlignRight
+			}
+
+			if s.MarginL > 0 {
+				r.ml = s.MarginL
+			}
+			if s.MarginR > 0 {
+				r.mr = s.MarginR
+			}
+			if s.MarginV > 0 {
+				r.mv = s.MarginV
+			}
+		}
+
+		if cueStyle.MaxWidth == 0 {
+			cueStyle.MaxWidth = frameWidth - (r.ml + r.mr)
+		}
+		cueStyle.MaxWidth = int(float64(cueStyle.MaxWidth) / scale)
+
+		r.img = RenderText(cue.Text, cueStyle)
+
+		if s != nil && s.Bold {
+			r.img = emboldenNRGBA(r.img)
+		}
+		if s != nil && s.Italic {
+			r.img = slantNRGBA(r.img, .2)
+		}
+
+		if scale != 1 {
+			b := r.img.Bounds()
+			w := intMax(int(float64(b.Dx())*scale+.5), 1)
+			h := intMax(int(float64(b.Dy())*scale+.5), 1)
+
+			img := image.NewNRGBA(image.Rect(0, 0, w, h))
+			img.Pix = resizeRGBA(r.img.Pix, b.Dx(), b.Dy(), w, h, Bilinear)
+			r.img = img
+		}
+		cache[key] = r
+
+		return r
+	}
+
+	//forget the cues which ended before t. A frame which is transformed out of order renders its cue again.
+	evict := func(t float32) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for key := range cache {
+			if subs.Cues[key.index].End <= t {
+				delete(cache, key)
+			}
+		}
+	}
+
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			evict(f.Time)
+
+			for i, cue := range subs.Cues {
+				if f.Time < cue.Start || f.Time >= cue.End {
+					continue
+				}
+
+				r := render(i, f.Width)
+				b := r.img.Bounds()
+
+				var x, y int
+
+				switch r.align % 3 {
+				case 1:
+					x = r.ml
+				case 2:
+					x = (f.Width - b.Dx()) / 2
+				case 0:
+					x = f.Width - r.mr - b.Dx()
+				}
+
+				switch {
+				case r.align <= 3:
+					y = f.Height - r.mv - b.Dy()
+				case r.align <= 6:
+					y = (f.Height - b.Dy()) / 2
+				default:
+					y = r.mv
+				}
+
+				drawOverRGBA(f.Data, f.Width, f.Height, r.img.Pix, b.Dx(), b.Dy(), x, y, 255)
+			}
+		},
+	}
+}
+
+// emboldenNRGBA makes the text of the image 1 pixel wider by combining each pixel with its left neighbour
+func emboldenNRGBA(src *image.NRGBA) *image.NRGBA {
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx()+1, b.Dy()))
+
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			o := dst.PixOffset(x, y)
+
+			//the most opaque of the pixel and its left neighbour
+			for _, sx := range [2]int{x, x - 1} {
+				if sx < 0 || sx >= b.Dx() {
+					continue
+				}
+				p := src.Pix[src.PixOffset(b.Min.X+sx, b.Min.Y+y):]
+				if p[3] > dst.Pix[o+3] {
+					copy(dst.Pix[o:o+4], p[:4])
+				}
+			}
+		}
+	}
+
+	return dst
+}
+
+// slantNRGBA shears the image to the right for a fake italic. The top row moves slant pixels per row of height.
+func slantNRGBA(src *image.NRGBA, slant float64) *image.NRGBA {
+	b := src.Bounds()
+	extra := int(math.Ceil(float64(b.Dy()) * slant))
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx()+extra, b.Dy()))
+
+	for y := 0; y < b.Dy(); y++ {
+		shift := int(float64(b.Dy()-1-y)*slant + .5)
+		s := src.PixOffset(b.Min.X, b.Min.Y+y)
+		copy(dst.Pix[dst.PixOffset(shift, y):], src.Pix[s:s+b.Dx()*4])
+	}
+
+	return dst
+}
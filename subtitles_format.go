@@ -0,0 +1,344 @@
+package gomovie
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var invalidTimestampError = errors.New("Invalid subtitle timestamp")
+
+// readLines reads all the lines without line endings and byte order mark
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+
+	if len(lines) > 0 {
+		lines[0] = strings.TrimPrefix(lines[0], "\ufeff")
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseTimestamp parses timestamps like 01:02:03,456 (srt), 01:02:03.456 or 02:03.456 (vtt) and 1:02:03.45 (ass)
+func parseTimestamp(s string) (float32, error) {
+	parts := strings.Split(strings.Replace(strings.TrimSpace(s), ",", ".", 1), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, invalidTimestampError
+	}
+
+	var t float64
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, invalidTimestampError
+		}
+		t = t*60 + v
+	}
+
+	return float32(t), nil
+}
+
+// formatTimestamp formats the time as hh:mm:ss with the given separator and number of decimals
+func formatTimestamp(t float32, separator string, decimals int) string {
+	scale := 1
+	for i := 0; i < decimals; i++ {
+		scale *= 10
+	}
+
+	total := int(float64(t)*float64(scale) + .5)
+	frac := total % scale
+	secs := total / scale
+
+	hours := fmt.Sprintf("%02d", secs/3600)
+	if decimals == 2 { //ass uses a single digit for the hours
+		hours = strconv.Itoa(secs / 3600)
+	}
+
+	return fmt.Sprintf("%s:%02d:%02d%s%0*d", hours, secs/60%60, secs%60, separator, decimals, frac)
+}
+
+// parseCueTiming parses a line like "00:00:01,000 --> 00:00:04,000" with optional vtt settings after it
+func parseCueTiming(line string) (start, end float32, ok bool) {
+	parts := strings.SplitN(line, "-->", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	fields := strings.Fields(parts[1])
+	if len(fields) == 0 {
+		return 0, 0, false
+	}
+
+	var err error
+	if start, err = parseTimestamp(parts[0]); err != nil {
+		return 0, 0, false
+	}
+	if end, err = parseTimestamp(fields[0]); err != nil {
+		return 0, 0, false
+	}
+
+	return start, end, true
+}
+
+// parseCueBlocks parses the srt and vtt cue blocks. A block starts with an optional identifier followed by the timing line and the text.
+func parseCueBlocks(lines []string) *Subtitles {
+	subs := new(Subtitles)
+
+	for i := 0; i < len(lines); i++ {
+		start, end, ok := parseCueTiming(lines[i])
+		if !ok {
+			continue
+		}
+
+		var text []string
+		for i++; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+			text = append(text, lines[i])
+		}
+
+		subs.Cues = append(subs.Cues, Cue{Start: start, End: end, Text: strings.Join(text, "\n")})
+	}
+
+	return subs
+}
+
+// ParseSRT parses SubRip subtitles
+func ParseSRT(r io.Reader) (*Subtitles, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseCueBlocks(lines), nil
+}
+
+// ParseWebVTT parses WebVTT subtitles. NOTE, STYLE and REGION blocks and cue settings are ignored.
+func ParseWebVTT(r io.Reader) (*Subtitles, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "WEBVTT") {
+		return nil, errors.New("Missing WEBVTT header")
+	}
+
+	//remove the blocks which are not cues so their contents can't be mistaken for cues
+	var cueLines []string
+	skip := false
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			skip = false
+		} else if len(cueLines) == 0 || strings.TrimSpace(cueLines[len(cueLines)-1]) == "" {
+			skip = strings.HasPrefix(line, "NOTE") || strings.HasPrefix(line, "STYLE") || strings.HasPrefix(line, "REGION")
+		}
+
+		if !skip {
+			cueLines = append(cueLines, line)
+		}
+	}
+
+	return parseCueBlocks(cueLines), nil
+}
+
+// WriteSRT writes the cues as SubRip
+func (s *Subtitles) WriteSRT(w io.Writer) error {
+	for i, cue := range s.Cues {
+		_, err := fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1,
+			formatTimestamp(cue.Start, ",", 3),
+			formatTimestamp(cue.End, ",", 3),
+			cue.Text,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteWebVTT writes the cues as WebVTT
+func (s *Subtitles) WriteWebVTT(w io.Writer) error {
+	if _, err := io.WriteString(w, "WEBVTT\n\n"); err != nil {
+		return err
+	}
+
+	for _, cue := range s.Cues {
+		_, err := fmt.Fprintf(w, "%s --> %s\n%s\n\n",
+			formatTimestamp(cue.Start, ".", 3),
+			formatTimestamp(cue.End, ".", 3),
+			cue.Text,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var assOverrideTags = regexp.MustCompile(`\{[^}]*\}`)
+
+// parseASSColor parses colors like &H00BBGGRR. The alpha in ass is inverted (00 is opaque).
+func parseASSColor(s string) color.NRGBA {
+	s = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "&H"), "&")
+	v, _ := strconv.ParseUint(s, 16, 32)
+	return color.NRGBA{R: uint8(v), G: uint8(v >> 8), B: uint8(v >> 16), A: 255 - uint8(v>>24)}
+}
+
+func formatASSColor(c color.NRGBA) string {
+	return fmt.Sprintf("&H%02X%02X%02X%02X", 255-c.A, c.B, c.G, c.R)
+}
+
+// ParseASS parses Advanced SubStation Alpha subtitles. Only the basic style properties are used and override tags are removed from the text.
+func ParseASS(r io.Reader) (*Subtitles, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	subs := new(Subtitles)
+
+	var (
+		section string
+		format  []string
+	)
+
+	//returns the values of the line by the names of the format. The last field may contain commas.
+	fields := func(value string) map[string]string {
+		values := strings.SplitN(value, ",", len(format))
+		m := make(map[string]string, len(format))
+		for i, name := range format {
+			if i < len(values) {
+				m[name] = strings.TrimSpace(values[i])
+			}
+		}
+		return m
+	}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToLower(line)
+			format = nil
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key, value := parts[0], strings.TrimSpace(parts[1])
+
+		if key == "Format" {
+			format = strings.Split(value, ",")
+			for i := range format {
+				format[i] = strings.TrimSpace(format[i])
+			}
+			continue
+		}
+
+		switch {
+		case key == "Style" && strings.HasPrefix(section, "[v4"):
+			f := fields(value)
+
+			fontSize, _ := strconv.ParseFloat(f["Fontsize"], 64)
+			outline, _ := strconv.ParseFloat(f["Outline"], 64)
+			shadow, _ := strconv.ParseFloat(f["Shadow"], 64)
+			alignment, _ := strconv.Atoi(f["Alignment"])
+			marginL, _ := strconv.Atoi(f["MarginL"])
+			marginR, _ := strconv.Atoi(f["MarginR"])
+			marginV, _ := strconv.Atoi(f["MarginV"])
+
+			subs.Styles = append(subs.Styles, SubtitleStyle{
+				Name:         f["Name"],
+				FontName:     f["Fontname"],
+				FontSize:     fontSize,
+				Bold:         f["Bold"] != "" && f["Bold"] != "0",
+				Italic:       f["Italic"] != "" && f["Italic"] != "0",
+				PrimaryColor: parseASSColor(f["PrimaryColour"]),
+				OutlineColor: parseASSColor(f["OutlineColour"]),
+				BackColor:    parseASSColor(f["BackColour"]),
+				Outline:      outline,
+				Shadow:       shadow,
+				Alignment:    alignment,
+				MarginL:      marginL,
+				MarginR:      marginR,
+				MarginV:      marginV,
+			})
+
+		case key == "Dialogue" && section == "[events]":
+			f := fields(value)
+
+			start, err := parseTimestamp(f["Start"])
+			if err != nil {
+				return nil, err
+			}
+
+			end, err := parseTimestamp(f["End"])
+			if err != nil {
+				return nil, err
+			}
+
+			text := assOverrideTags.ReplaceAllString(f["Text"], "")
+			text = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(text)
+
+			subs.Cues = append(subs.Cues, Cue{Start: start, End: end, Text: text, Style: f["Style"]})
+		}
+	}
+
+	return subs, nil
+}
+
+// WriteASS writes the styles and cues as Advanced SubStation Alpha
+func (s *Subtitles) WriteASS(w io.Writer) error {
+	b := bufio.NewWriter(w)
+
+	b.WriteString("[Script Info]\nScriptType: v4.00+\n\n")
+
+	b.WriteString("[V4+ Styles]\n")
+	b.WriteString("Format: Name, Fontname, Fontsize, PrimaryColour, OutlineColour, BackColour, Bold, Italic, Outline, Shadow, Alignment, MarginL, MarginR, MarginV\n")
+
+	boolValue := func(v bool) int {
+		if v {
+			return -1
+		}
+		return 0
+	}
+
+	for _, st := range s.Styles {
+		fmt.Fprintf(b, "Style: %s,%s,%g,%s,%s,%s,%d,%d,%g,%g,%d,%d,%d,%d\n",
+			st.Name, st.FontName, st.FontSize,
+			formatASSColor(st.PrimaryColor), formatASSColor(st.OutlineColor), formatASSColor(st.BackColor),
+			boolValue(st.Bold), boolValue(st.Italic),
+			st.Outline, st.Shadow, st.Alignment,
+			st.MarginL, st.MarginR, st.MarginV,
+		)
+	}
+
+	b.WriteString("\n[Events]\nFormat: Layer, Start, End, Style, Text\n")
+
+	for _, cue := range s.Cues {
+		style := cue.Style
+		if style == "" {
+			style = "Default"
+		}
+
+		fmt.Fprintf(b, "Dialogue: 0,%s,%s,%s,%s\n",
+			formatTimestamp(cue.Start, ".", 2),
+			formatTimestamp(cue.End, ".", 2),
+			style,
+			strings.Replace(cue.Text, "\n", `\N`, -1),
+		)
+	}
+
+	return b.Flush()
+}
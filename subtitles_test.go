@@ -0,0 +1,202 @@
+package gomovie_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+const testSRT = `1
+00:00:01,000 --> 00:00:03,500
+Hello
+
+2
+00:00:04,000 --> 00:00:06,000
+Second line
+with a break
+`
+
+const testASS = `[Script Info]
+ScriptType: v4.00+
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, OutlineColour, BackColour, Bold, Italic, Outline, Shadow, Alignment, MarginL, MarginR, MarginV
+Style: Top,Arial,20,&H0000FFFF,&H00000000,&H80000000,-1,0,2,0,8,10,10,10
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:02.50,Top,,0,0,0,,{\b1}Hi, there\Nfriend
+`
+
+func TestParseSubtitles(t *testing.T) {
+	srt, err := gomovie.ParseSRT(strings.NewReader(testSRT))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(srt.Cues) != 2 || srt.Cues[1].Text != "Second line\nwith a break" || srt.Cues[0].End != 3.5 {
+		t.Fatalf("Unexpected cues %+v", srt.Cues)
+	}
+
+	//write as vtt and parse it again
+	buf := new(bytes.Buffer)
+	if err := srt.WriteWebVTT(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	vtt, err := gomovie.ParseWebVTT(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vtt.Cues) != 2 || vtt.Cues[1] != srt.Cues[1] {
+		t.Fatalf("Cues changed after writing %+v", vtt.Cues)
+	}
+
+	ass, err := gomovie.ParseASS(strings.NewReader(testASS))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ass.Cues) != 1 || ass.Cues[0].Text != "Hi, there\nfriend" || ass.Cues[0].Style != "Top" {
+		t.Fatalf("Unexpected cues %+v", ass.Cues)
+	}
+
+	if len(ass.Styles) != 1 || ass.Styles[0].PrimaryColor != (color.NRGBA{255, 255, 0, 255}) || ass.Styles[0].Alignment != 8 {
+		t.Fatalf("Unexpected styles %+v", ass.Styles)
+	}
+}
+
+func TestSliceSubtitles(t *testing.T) {
+	srt, _ := gomovie.ParseSRT(strings.NewReader(testSRT))
+
+	sliced := srt.Slice(&gomovie.Range{Start: 2, Duration: 3})
+
+	if len(sliced.Cues) != 2 || sliced.Cues[0].Start != 0 || sliced.Cues[0].End != 1.5 || sliced.Cues[1].End != 3 {
+		t.Fatalf("Unexpected sliced cues %+v", sliced.Cues)
+	}
+
+	clip := &gomovie.Video{Subtitles: sliced, FrameReader: gomovie.NewNullFrameReader(&gomovie.FrameReaderInfo{Width: 8, Height: 8, FrameRate: 25, Duration: 3})}
+	joined := gomovie.Concat(clip, clip)
+
+	if len(joined.Subtitles.Cues) != 4 || joined.Subtitles.Cues[2].Start != 3 {
+		t.Fatalf("Unexpected concatenated cues %+v", joined.Subtitles.Cues)
+	}
+}
+
+func TestBurnSubtitles(t *testing.T) {
+	srt, _ := gomovie.ParseSRT(strings.NewReader(testSRT))
+
+	transform := gomovie.NewSubtitleTransform(srt, gomovie.TextStyle{})
+
+	f := solidFrame(320, 180, color.NRGBA{0, 0, 0, 255})
+	f.Time = 2
+	transform.Transform(f)
+
+	var bright int
+	for i := 0; i < len(f.Data); i += 4 {
+		if f.Data[i] > 128 {
+			bright++
+		}
+	}
+
+	if bright == 0 {
+		t.Fatal("Cue was not burned into the frame")
+	}
+}
+
+func TestBurnSubtitlesFrameSizes(t *testing.T) {
+	srt, _ := gomovie.ParseSRT(strings.NewReader("1\n00:00:00,000 --> 00:00:02,000\nA rather long line of text which has to wrap on small frames\n"))
+
+	transform := gomovie.NewSubtitleTransform(srt, gomovie.TextStyle{})
+
+	//horizontal extent of the burned text
+	textWidth := func(f *gomovie.Frame) int {
+		min, max := f.Width, -1
+		for i := 0; i < len(f.Data); i += 4 {
+			if f.Data[i] > 128 {
+				x := (i / 4) % f.Width
+				if x < min {
+					min = x
+				}
+				if x > max {
+					max = x
+				}
+			}
+		}
+		return max - min + 1
+	}
+
+	small := solidFrame(120, 180, color.NRGBA{0, 0, 0, 255})
+	transform.Transform(small)
+
+	large := solidFrame(640, 180, color.NRGBA{0, 0, 0, 255})
+	transform.Transform(large)
+
+	if w := textWidth(large); w <= 120 {
+		t.Fatalf("Expected the text not to be wrapped for the large frame but it is %v wide", w)
+	}
+}
+
+func TestBurnSubtitlesASSStyle(t *testing.T) {
+	type burned struct {
+		bounds image.Rectangle
+		count  int
+
+		//first bright x of the top and bottom row
+		topX, bottomX int
+	}
+
+	burn := func(style gomovie.SubtitleStyle) (b burned) {
+		style.Name = "S"
+		style.PrimaryColor = color.NRGBA{255, 255, 255, 255}
+
+		subs := &gomovie.Subtitles{
+			Cues:   []gomovie.Cue{{Start: 0, End: 1, Text: "Hi", Style: "S"}},
+			Styles: []gomovie.SubtitleStyle{style},
+		}
+
+		f := solidFrame(320, 180, color.NRGBA{0, 0, 0, 255})
+		gomovie.NewSubtitleTransform(subs, gomovie.TextStyle{}).Transform(f)
+
+		for i := 0; i < len(f.Data); i += 4 {
+			if f.Data[i] <= 128 {
+				continue
+			}
+
+			x, y := (i/4)%f.Width, (i/4)/f.Width
+			if b.count == 0 {
+				b.bounds = image.Rect(x, y, x+1, y+1)
+				b.topX = x
+			}
+			if b.count == 0 || y >= b.bounds.Max.Y {
+				b.bottomX = x //first pixel of a new row
+			}
+			b.bounds = b.bounds.Union(image.Rect(x, y, x+1, y+1))
+			b.count++
+		}
+		return
+	}
+
+	if right := burn(gomovie.SubtitleStyle{Alignment: 3, MarginL: 100, MarginR: 5}); right.bounds.Max.X < 300 {
+		t.Fatalf("Expected a right aligned cue at the right margin but it ends at %v", right.bounds.Max.X)
+	}
+
+	regular := burn(gomovie.SubtitleStyle{Alignment: 2, FontSize: 13})
+
+	if big := burn(gomovie.SubtitleStyle{Alignment: 2, FontSize: 39}); big.bounds.Dy() < regular.bounds.Dy()*2 {
+		t.Fatalf("Expected a font size of 39 to be about 3 times as high as 13 but got %v and %v", big.bounds.Dy(), regular.bounds.Dy())
+	}
+
+	if bold := burn(gomovie.SubtitleStyle{Alignment: 2, FontSize: 13, Bold: true}); bold.count <= regular.count {
+		t.Fatalf("Expected more pixels for bold text but got %v and %v", bold.count, regular.count)
+	}
+
+	if italic := burn(gomovie.SubtitleStyle{Alignment: 2, FontSize: 13, Italic: true}); italic.topX <= italic.bottomX {
+		t.Fatalf("Expected the top of italic text to be slanted to the right but got %v and %v", italic.topX, italic.bottomX)
+	}
+}
@@ -0,0 +1,105 @@
+package gomovie
+
+// TemporalTransform is a frame transform which sees the frames around the current frame. The frames are collected in order
+// while the transform itself runs in parallel like the other transforms of a FrameTransformer. Use it in a FrameTransform.
+type TemporalTransform struct {
+	// Before and After are the number of previous and next frames in the window
+	Before, After int
+
+	// Transform modifies f which is a copy of window[current] with its own data. The window has up to Before frames before
+	// and After frames after the current frame and is shorter at the start and end of the reader (or a Slice).
+	// The frames of the window should not be modified. All frames have the same size.
+	Transform func(f *Frame, window []*Frame, current int)
+}
+
+// weightedBlend replaces the data of f with the weighted mean of the frames. Weights of 0 skip a frame.
+func weightedBlend(f *Frame, window []*Frame, weights []float64) {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		return
+	}
+
+	for i := range f.Data {
+		var v float64
+		for j, w := range weights {
+			if w != 0 {
+				v += float64(window[j].Data[i]) * w
+			}
+		}
+		f.Data[i] = uint8(v/total + .5)
+	}
+}
+
+// NewFrameBlendTransform creates a FrameTransform which replaces each frame by the mean of itself and the frames around it.
+// Gives a motion blur over before + after + 1 frames.
+func NewFrameBlendTransform(before, after int) FrameTransform {
+	return FrameTransform{
+		Temporal: &TemporalTransform{
+			Before: before,
+			After:  after,
+			Transform: func(f *Frame, window []*Frame, current int) {
+				weights := make([]float64, len(window))
+				for i := range weights {
+					weights[i] = 1
+				}
+				weightedBlend(f, window, weights)
+			},
+		},
+	}
+}
+
+// NewGhostingTransform creates a FrameTransform which leaves a trail of the previous frames. Each earlier frame is weighted
+// decay (0 - 1) times the frame after it so the trail fades out.
+func NewGhostingTransform(length int, decay float64) FrameTransform {
+	return FrameTransform{
+		Temporal: &TemporalTransform{
+			Before: length,
+			Transform: func(f *Frame, window []*Frame, current int) {
+				weights := make([]float64, len(window))
+				w := 1.
+				for i := current; i >= 0; i-- {
+					weights[i] = w
+					w *= decay
+				}
+				weightedBlend(f, window, weights)
+			},
+		},
+	}
+}
+
+// NewTemporalDenoiseTransform creates a FrameTransform which averages each color channel with the same pixel in the frames
+// around it (radius frames on each side). Only values which differ at most threshold (0 - 255) from the current value are
+// averaged so moving edges don't leave ghosts while static noise is removed.
+func NewTemporalDenoiseTransform(radius int, threshold uint8) FrameTransform {
+	return FrameTransform{
+		Temporal: &TemporalTransform{
+			Before: radius,
+			After:  radius,
+			Transform: func(f *Frame, window []*Frame, current int) {
+				t := int(threshold)
+				src := window[current].Data
+
+				for i := range f.Data {
+					if i%4 == 3 {
+						continue
+					}
+
+					c := int(src[i])
+					sum, n := 0, 0
+					for _, w := range window {
+						v := int(w.Data[i])
+						if d := v - c; d <= t && d >= -t {
+							sum += v
+							n++
+						}
+					}
+
+					f.Data[i] = uint8((sum + n/2) / n)
+				}
+			},
+		},
+	}
+}
@@ -0,0 +1,146 @@
+package gomovie_test
+
+import (
+	"image/color"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func readFrames(t *testing.T, reader gomovie.FrameReader) (frames []*gomovie.Frame) {
+	for {
+		f, err := reader.ReadFrame()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, f)
+	}
+}
+
+func TestTemporalWindow(t *testing.T) {
+	var mu sync.Mutex
+	windows := map[int][]int{}
+
+	record := gomovie.FrameTransform{
+		Temporal: &gomovie.TemporalTransform{
+			Before: 2,
+			After:  1,
+			Transform: func(f *gomovie.Frame, window []*gomovie.Frame, current int) {
+				var indexes []int
+				for _, w := range window {
+					indexes = append(indexes, w.Index)
+				}
+
+				mu.Lock()
+				windows[window[current].Index] = indexes
+				mu.Unlock()
+			},
+		},
+	}
+
+	frames := readFrames(t, gomovie.NewFrameTransformer(colorClip(color.White, .4)).AddTransform(record))
+	if len(frames) != 10 {
+		t.Fatalf("Expected 10 frames but got %v", len(frames))
+	}
+
+	expected := map[int][]int{0: {0, 1}, 5: {3, 4, 5, 6}, 9: {7, 8, 9}}
+	for i, e := range expected {
+		if w := windows[i]; len(w) != len(e) || w[0] != e[0] || w[len(w)-1] != e[len(e)-1] {
+			t.Fatalf("Expected the window %v for frame %v but got %v", e, i, w)
+		}
+	}
+}
+
+func TestFrameBlendTransform(t *testing.T) {
+	reader := gomovie.Concat(colorClip(color.White, .2), colorClip(color.Black, .2)).FrameReader
+	frames := readFrames(t, gomovie.NewFrameTransformer(reader).AddTransform(gomovie.NewFrameBlendTransform(1, 1)))
+
+	for i, v := range map[int]uint8{3: 255, 4: 170, 5: 85, 6: 0} {
+		if frames[i].Data[0] != v {
+			t.Fatalf("Expected %v at frame %v but got %v", v, i, frames[i].Data[0])
+		}
+	}
+
+	//the window is shorter at the start
+	if frames[0].Data[0] != 255 {
+		t.Fatalf("Expected white at the start but got %v", frames[0].Data[0])
+	}
+}
+
+func TestGhostingTransform(t *testing.T) {
+	reader := gomovie.Concat(colorClip(color.White, .2), colorClip(color.Black, .2)).FrameReader
+	frames := readFrames(t, gomovie.NewFrameTransformer(reader).AddTransform(gomovie.NewGhostingTransform(2, .5)))
+
+	//the first black frame still shows the white frames at .5 and .25
+	if v := frames[5].Data[0]; v != 109 {
+		t.Fatalf("Expected a trail of 109 but got %v", v)
+	}
+
+	if v := frames[7].Data[0]; v != 0 {
+		t.Fatalf("Expected the trail to be gone but got %v", v)
+	}
+}
+
+func TestTemporalDenoiseTransform(t *testing.T) {
+	var clips []interface{}
+	for i := 0; i < 5; i++ {
+		clips = append(clips, colorClip(color.Gray{100}, .04), colorClip(color.Gray{104}, .04))
+	}
+	clips = append(clips, colorClip(color.White, .2))
+
+	frames := readFrames(t, gomovie.NewFrameTransformer(gomovie.Concat(clips...).FrameReader).AddTransform(gomovie.NewTemporalDenoiseTransform(1, 10)))
+
+	if v := frames[2].Data[0]; v != 103 {
+		t.Fatalf("Expected the noise to be averaged to 103 but got %v", v)
+	}
+
+	//the cut is not blended
+	if v := frames[10].Data[0]; v != 255 {
+		t.Fatalf("Expected white after the cut but got %v", v)
+	}
+}
+
+func TestTemporalAfterResize(t *testing.T) {
+	transformer := gomovie.NewFrameTransformer(colorClip(color.White, .2)).
+		AddTransform(gomovie.NewResizeTransform(4, 4, gomovie.DefaultFit)).
+		AddTransform(gomovie.NewFrameBlendTransform(1, 1))
+
+	frames := readFrames(t, transformer)
+	if len(frames) != 5 || frames[2].Width != 4 || len(frames[2].Data) != 64 {
+		t.Fatalf("Expected 5 resized frames but got %v", len(frames))
+	}
+}
+
+func TestPipelineWindow(t *testing.T) {
+	var read int32
+	sum := gomovie.Stage{
+		Parallel: 3,
+		Before:   1,
+		After:    1,
+		Process: func(item interface{}) (interface{}, error) {
+			w := item.(*gomovie.StageWindow)
+			total := 0
+			for _, v := range w.Items {
+				total += v.(int)
+			}
+			return total, nil
+		},
+	}
+
+	p := gomovie.NewPipeline(counter(5, &read), 1, sum)
+
+	for _, e := range []int{1, 3, 6, 9, 7} {
+		v, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.(int) != e {
+			t.Fatalf("Expected %v but got %v", e, v)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package gomovie
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"os"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// TextAlign describes the horizontal alignment of the lines of a text
+type TextAlign int
+
+const (
+	AlignLeft TextAlign = iota
+	AlignCenter
+	AlignRight
+)
+
+// TextStyle describes how a text is rendered
+type TextStyle struct {
+	// Face is the font face. The embedded 7x13 bitmap font is used when nil. See LoadFontFace for TTF and OTF fonts.
+	Face font.Face
+
+	// Color of the text. White when nil.
+	Color color.Color
+
+	// Outline around the glyphs. No outline when OutlineWidth is 0.
+	OutlineColor color.Color
+	OutlineWidth int
+
+	// Drop shadow. No shadow when ShadowColor is nil.
+	ShadowColor  color.Color
+	ShadowOffset image.Point
+
+	Align TextAlign
+
+	// LineSpacing is multiplied with the line height of the font. 1 when 0.
+	LineSpacing float64
+
+	// MaxWidth in pixels after which lines are wrapped. No wrapping when 0.
+	MaxWidth int
+
+	// Background box behind the text. No box when nil. Padding is the space between the text and the edge of the box.
+	Background color.Color
+	Padding    int
+}
+
+// LoadFontFace loads a TTF or OTF font file and creates a face with the given size in pixels
+func LoadFontFace(path string, size float64) (font.Face, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := opentype.Parse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+func (s *TextStyle) face() font.Face {
+	if s.Face == nil {
+		return basicfont.Face7x13
+	}
+	return s.Face
+}
+
+// wrapText splits the text in lines. Lines longer than maxWidth are wrapped on spaces.
+func wrapText(text string, face font.Face, maxWidth int) (lines []string) {
+	for _, paragraph := range strings.Split(text, "\n") {
+		if maxWidth <= 0 {
+			lines = append(lines, paragraph)
+			continue
+		}
+
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+
+			if line != "" && font.MeasureString(face, candidate).Ceil() > maxWidth {
+				lines = append(lines, line)
+				line = word
+			} else {
+				line = candidate
+			}
+		}
+		lines = append(lines, line)
+	}
+	return
+}
+
+// RenderText renders the text with the style into a new image which is just big enough to contain it
+func RenderText(text string, style TextStyle) *image.NRGBA {
+	face := style.face()
+	metrics := face.Metrics()
+
+	lines := wrapText(text, face, style.MaxWidth)
+
+	spacing := style.LineSpacing
+	if spacing == 0 {
+		spacing = 1
+	}
+
+	lineHeight := int(math.Ceil(float64(metrics.Height.Ceil()) * spacing))
+
+	var textWidth int
+	lineWidths := make([]int, len(lines))
+	for i, line := range lines {
+		lineWidths[i] = font.MeasureString(face, line).Ceil()
+		textWidth = intMax(textWidth, lineWidths[i])
+	}
+
+	textHeight := lineHeight*(len(lines)-1) + metrics.Ascent.Ceil() + metrics.Descent.Ceil()
+
+	//space needed around the glyphs for the outline and shadow
+	var shadowOffset image.Point
+	if style.ShadowColor != nil {
+		shadowOffset = style.ShadowOffset
+	}
+
+	margin := style.OutlineWidth + style.Padding
+	left := margin + intMax(-shadowOffset.X, 0)
+	top := margin + intMax(-shadowOffset.Y, 0)
+
+	width := textWidth + left + margin + intMax(shadowOffset.X, 0)
+	height := textHeight + top + margin + intMax(shadowOffset.Y, 0)
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	if style.Background != nil {
+		draw.Draw(img, img.Bounds(), image.NewUniform(style.Background), image.Point{}, draw.Src)
+	}
+
+	drawLines := func(c color.Color, offset image.Point) {
+		d := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face}
+
+		for i, line := range lines {
+			x := left
+			switch style.Align {
+			case AlignCenter:
+				x += (textWidth - lineWidths[i]) / 2
+			case AlignRight:
+				x += textWidth - lineWidths[i]
+			}
+
+			y := top + metrics.Ascent.Ceil() + i*lineHeight
+
+			d.Dot = fixed.P(x+offset.X, y+offset.Y)
+			d.DrawString(line)
+		}
+	}
+
+	if style.ShadowColor != nil {
+		drawLines(style.ShadowColor, shadowOffset)
+	}
+
+	if style.OutlineWidth > 0 && style.OutlineColor != nil {
+		r := style.OutlineWidth
+		for y := -r; y <= r; y++ {
+			for x := -r; x <= r; x++ {
+				if x*x+y*y <= r*r && (x != 0 || y != 0) {
+					drawLines(style.OutlineColor, image.Pt(x, y))
+				}
+			}
+		}
+	}
+
+	c := style.Color
+	if c == nil {
+		c = color.White
+	}
+	drawLines(c, image.Point{})
+
+	return img
+}
+
+// TextOverlay describes a text which is drawn on top of frames
+type TextOverlay struct {
+	Text  string
+	Style TextStyle
+
+	// Position of the top left corner of the text in pixels
+	Position AnimatedPoint
+
+	// Opacity between 0 and 1. Fully opaque when nil.
+	Opacity Keyframes
+
+	// The text is only visible from Start until Start + Duration. Always visible when Duration is 0.
+	Start    float32
+	Duration float32
+}
+
+// NewTextTransform creates a FrameTransform which draws the overlay on each frame
+func NewTextTransform(overlay TextOverlay) FrameTransform {
+	img := RenderText(overlay.Text, overlay.Style)
+	b := img.Bounds()
+
+	return FrameTransform{
+		Transform: func(f *Frame) {
+			if overlay.Duration > 0 && (f.Time < overlay.Start || f.Time >= overlay.Start+overlay.Duration) {
+				return
+			}
+
+			opacity := 1.
+			if overlay.Opacity != nil {
+				opacity = overlay.Opacity.At(f.Time)
+			}
+
+			if opacity <= 0 {
+				return
+			}
+
+			x, y := overlay.Position.At(f.Time)
+
+			drawOverRGBA(f.Data, f.Width, f.Height, img.Pix, b.Dx(), b.Dy(), int(math.Floor(x+.5)), int(math.Floor(y+.5)), uint32(clampUint8(float32(opacity*255))))
+		},
+	}
+}
+
+// NewTextClip creates a FrameReader which shows the text centered on a transparent background.
+// The size, frame rate and duration are taken from info.
+func NewTextClip(text string, style TextStyle, info *FrameReaderInfo) FrameReader {
+	b := RenderText(text, style).Bounds()
+
+	transformer := NewFrameTransformer(NewNullFrameReader(info))
+	transformer.AddTransform(NewTextTransform(TextOverlay{
+		Text:  text,
+		Style: style,
+		Position: AnimatedPoint{
+			X: Constant(float64(info.Width-b.Dx()) / 2),
+			Y: Constant(float64(info.Height-b.Dy()) / 2),
+		},
+	}))
+
+	return transformer
+}
@@ -0,0 +1,43 @@
+package gomovie_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestRenderText(t *testing.T) {
+	single := gomovie.RenderText("Hello world", gomovie.TextStyle{})
+	wrapped := gomovie.RenderText("Hello world", gomovie.TextStyle{MaxWidth: 40})
+
+	if wrapped.Bounds().Dy() <= single.Bounds().Dy() {
+		t.Fatal("Expected the wrapped text to have multiple lines")
+	}
+
+	boxed := gomovie.RenderText("Hi", gomovie.TextStyle{Background: color.Black, Padding: 4})
+	if c := boxed.NRGBAAt(0, 0); c != (color.NRGBA{0, 0, 0, 255}) {
+		t.Fatalf("Expected the background box in the corner but got %v", c)
+	}
+}
+
+func TestTextClip(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 160, Height: 90, FrameRate: 25, Duration: 1}
+	clip := gomovie.NewTextClip("Title", gomovie.TextStyle{Color: color.White}, info)
+
+	f, err := clip.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var opaque int
+	for i := 3; i < len(f.Data); i += 4 {
+		if f.Data[i] > 0 {
+			opaque++
+		}
+	}
+
+	if opaque == 0 {
+		t.Fatal("No text was rendered in the frame")
+	}
+}
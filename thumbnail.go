@@ -0,0 +1,395 @@
+package gomovie
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+)
+
+var invalidStoryboardIntervalError = errors.New("Storyboard interval should be above 0")
+
+// ThumbnailSelection describes how Thumbnails picks the frames
+type ThumbnailSelection int
+
+const (
+	// EvenlySpaced picks the frame in the middle of each of Count equal parts
+	EvenlySpaced ThumbnailSelection = iota
+	// SceneBased picks the frame in the middle of the Count longest shots. Detecting the shots reads all the frames.
+	SceneBased
+	// Sharpest compares Candidates frames in each of Count equal parts and picks the least blurry one
+	Sharpest
+)
+
+// ThumbnailConfig describes the options of Thumbnails
+type ThumbnailConfig struct {
+	Count     int
+	Selection ThumbnailSelection
+
+	// Width and Height of the thumbnails. When one of them is 0 it follows the aspect ratio, when both are 0 the size of the frames is used.
+	Width, Height int
+
+	// Candidates is the number of frames compared for each thumbnail with Sharpest. 5 when 0.
+	Candidates int
+
+	// Detector is used for SceneBased. NewSceneDetector when nil.
+	Detector *SceneDetector
+}
+
+// Thumbnail is a scaled copy of the frame at Time
+type Thumbnail struct {
+	Time  float32
+	Image *image.NRGBA
+}
+
+// thumbnailSize returns the size of a thumbnail of a sw x sh frame which fits w x h
+func thumbnailSize(sw, sh, w, h int) (int, int) {
+	switch {
+	case w == 0 && h == 0:
+		return sw, sh
+	case h == 0:
+		return w, intMax(int(math.Floor(float64(sh*w)/float64(sw)+.5)), 1)
+	case w == 0:
+		return intMax(int(math.Floor(float64(sw*h)/float64(sh)+.5)), 1), h
+	}
+	return w, h
+}
+
+// GrabFrame returns the frame of the reader shown at time t. Only the frame is decoded by reading it from a Slice
+// which lets ffmpeg seek to the nearest keyframe. The reader itself is not read.
+func GrabFrame(reader FrameReader, t float32) (*Frame, error) {
+	frameDuration := 1 / reader.Info().FrameRate
+	duration := frameReaderDuration(reader)
+
+	//snap to the start of the frame and stay within the reader
+	t = float32(math.Floor(float64(t/frameDuration)+1e-3)) * frameDuration
+	t = float32(math.Max(math.Min(float64(t), float64(duration-frameDuration)), 0))
+
+	s := reader.Slice(&Range{Start: t, Duration: frameDuration})
+	defer s.Close()
+
+	f, err := s.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+
+	f.Time = t
+	return f, nil
+}
+
+// ThumbnailAt returns a thumbnail of the frame shown at time t
+func ThumbnailAt(reader FrameReader, t float32, width, height int) (*Thumbnail, error) {
+	f, err := GrabFrame(reader, t)
+	if err != nil {
+		return nil, err
+	}
+	return frameThumbnail(f, width, height), nil
+}
+
+func frameThumbnail(f *Frame, width, height int) *Thumbnail {
+	w, h := thumbnailSize(f.Width, f.Height, width, height)
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	copy(img.Pix, DefaultFit.apply(f.Data, f.Width, f.Height, w, h))
+
+	return &Thumbnail{Time: f.Time, Image: img}
+}
+
+// sharpness returns the variance of the laplacian of the luma. Blurry frames have a low variance.
+func sharpness(f *Frame) float64 {
+	w := intMin(f.Width, 256)
+	h := intMax(f.Height*w/intMax(f.Width, 1), 1)
+	y := lumaPlane(resizeRGBA(f.Data, f.Width, f.Height, w, h, Bilinear))
+
+	var sum, sumSq float64
+	var n int
+	for py := 1; py < h-1; py++ {
+		for px := 1; px < w-1; px++ {
+			i := py*w + px
+			l := 4*y[i] - y[i-1] - y[i+1] - y[i-w] - y[i+w]
+			sum += l
+			sumSq += l * l
+			n++
+		}
+	}
+
+	if n == 0 {
+		return 0
+	}
+
+	mean := sum / float64(n)
+	return sumSq/float64(n) - mean*mean
+}
+
+// thumbnailCandidates returns the times of the frames to consider for each thumbnail
+func thumbnailCandidates(reader FrameReader, config ThumbnailConfig) ([][]float32, error) {
+	duration := frameReaderDuration(reader)
+	count := intMax(config.Count, 1)
+
+	var candidates [][]float32
+
+	switch config.Selection {
+	case SceneBased:
+		detector := config.Detector
+		if detector == nil {
+			detector = NewSceneDetector()
+		}
+
+		scan := reader.Slice(&Range{Start: 0, Duration: duration})
+		shots, _, err := detector.Detect(scan)
+		scan.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		sort.SliceStable(shots, func(i, j int) bool { return shots[i].Duration > shots[j].Duration })
+		if len(shots) > count {
+			shots = shots[:count]
+		}
+		sort.Slice(shots, func(i, j int) bool { return shots[i].Start < shots[j].Start })
+
+		for _, s := range shots {
+			candidates = append(candidates, []float32{s.Start + s.Duration/2})
+		}
+	case Sharpest:
+		n := config.Candidates
+		if n <= 0 {
+			n = 5
+		}
+
+		for i := 0; i < count; i++ {
+			times := make([]float32, n)
+			for j := range times {
+				times[j] = duration * (float32(i) + (float32(j)+.5)/float32(n)) / float32(count)
+			}
+			candidates = append(candidates, times)
+		}
+	default:
+		for i := 0; i < count; i++ {
+			candidates = append(candidates, []float32{duration * (float32(i) + .5) / float32(count)})
+		}
+	}
+
+	return candidates, nil
+}
+
+// Thumbnails picks Count frames of the reader with the selection of the config and returns them scaled.
+// The frames are grabbed with GrabFrame so the reader itself is not read. SceneBased might return fewer thumbnails when there are fewer shots.
+func Thumbnails(reader FrameReader, config ThumbnailConfig) ([]*Thumbnail, error) {
+	candidates, err := thumbnailCandidates(reader, config)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnails := make([]*Thumbnail, 0, len(candidates))
+
+	for _, times := range candidates {
+		var (
+			best      *Frame
+			bestScore = -1.
+		)
+
+		for _, t := range times {
+			f, err := GrabFrame(reader, t)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(times) == 1 {
+				best = f
+				break
+			}
+
+			if score := sharpness(f); score > bestScore {
+				best, bestScore = f, score
+			}
+		}
+
+		thumbnails = append(thumbnails, frameThumbnail(best, config.Width, config.Height))
+	}
+
+	return thumbnails, nil
+}
+
+// formatClock formats the time as HH:MM:SS
+func formatClock(t float32) string {
+	secs := int(t)
+	return fmt.Sprintf("%02d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+}
+
+// ContactSheetStyle describes how a contact sheet is laid out
+type ContactSheetStyle struct {
+	// Columns of the grid. 4 when 0.
+	Columns int
+
+	// Spacing in pixels between the thumbnails and around the edge
+	Spacing    int
+	Background color.Color
+
+	// Timecodes draws the time of each thumbnail in the bottom right corner with the TimecodeStyle
+	Timecodes     bool
+	TimecodeStyle TextStyle
+}
+
+// DefaultContactSheetStyle is a grid of 4 columns on a black background with white timecodes on a dark box
+var DefaultContactSheetStyle = ContactSheetStyle{
+	Columns:       4,
+	Spacing:       8,
+	Background:    color.Black,
+	Timecodes:     true,
+	TimecodeStyle: TextStyle{Color: color.White, Background: color.NRGBA{0, 0, 0, 160}, Padding: 2},
+}
+
+// ContactSheet places the thumbnails in a grid. Each cell has the size of the first thumbnail.
+func ContactSheet(thumbnails []*Thumbnail, style ContactSheetStyle) *image.NRGBA {
+	if len(thumbnails) == 0 {
+		return image.NewNRGBA(image.Rect(0, 0, 0, 0))
+	}
+
+	columns := style.Columns
+	if columns <= 0 {
+		columns = 4
+	}
+	columns = intMin(columns, len(thumbnails))
+	rows := (len(thumbnails) + columns - 1) / columns
+
+	b := thumbnails[0].Image.Bounds()
+	cw, ch := b.Dx(), b.Dy()
+
+	w := columns*(cw+style.Spacing) + style.Spacing
+	h := rows*(ch+style.Spacing) + style.Spacing
+
+	sheet := image.NewNRGBA(image.Rect(0, 0, w, h))
+	if style.Background != nil {
+		fillRGBA(sheet.Pix, color.NRGBAModel.Convert(style.Background).(color.NRGBA))
+	}
+
+	for i, thumb := range thumbnails {
+		x := style.Spacing + i%columns*(cw+style.Spacing)
+		y := style.Spacing + i/columns*(ch+style.Spacing)
+
+		tb := thumb.Image.Bounds()
+		drawOverRGBA(sheet.Pix, w, h, thumb.Image.Pix, tb.Dx(), tb.Dy(), x, y, 255)
+
+		if style.Timecodes {
+			text := RenderText(formatClock(thumb.Time), style.TimecodeStyle)
+			t := text.Bounds()
+			drawOverRGBA(sheet.Pix, w, h, text.Pix, t.Dx(), t.Dy(), x+cw-t.Dx(), y+ch-t.Dy(), 255)
+		}
+	}
+
+	return sheet
+}
+
+// StoryboardConfig describes the options of NewStoryboard
+type StoryboardConfig struct {
+	// Interval in seconds between the tiles
+	Interval float32
+
+	// Width and Height of a tile. When one of them is 0 it follows the aspect ratio.
+	Width, Height int
+
+	// Columns and Rows of a sprite sheet. When Rows is 0 all the tiles are placed on a single sprite sheet.
+	Columns, Rows int
+}
+
+// DefaultStoryboardConfig creates a tile of 160 pixels wide every 5 seconds on sprite sheets of 10x10 tiles
+var DefaultStoryboardConfig = StoryboardConfig{Interval: 5, Width: 160, Columns: 10, Rows: 10}
+
+// Storyboard contains sprite sheets of tiles for scrubbing in video players
+type Storyboard struct {
+	Interval float32
+	Duration float32
+
+	TileWidth, TileHeight int
+	Columns, Rows         int
+
+	// Tiles is the number of tiles on all sprite sheets
+	Tiles   int
+	Sprites []*image.NRGBA
+}
+
+// NewStoryboard grabs a frame at the start of every interval and places the tiles on sprite sheets from left to right, top to bottom
+func NewStoryboard(reader FrameReader, config StoryboardConfig) (*Storyboard, error) {
+	if config.Interval <= 0 {
+		return nil, invalidStoryboardIntervalError
+	}
+
+	info := reader.Info()
+	duration := frameReaderDuration(reader)
+
+	columns := intMax(config.Columns, 1)
+	tw, th := thumbnailSize(info.Width, info.Height, config.Width, config.Height)
+
+	sb := &Storyboard{
+		Interval:   config.Interval,
+		Duration:   duration,
+		TileWidth:  tw,
+		TileHeight: th,
+		Columns:    columns,
+		Tiles:      intMax(int(math.Ceil(float64(duration/config.Interval)-1e-3)), 1),
+	}
+
+	sb.Rows = config.Rows
+	if sb.Rows <= 0 {
+		sb.Rows = (sb.Tiles + columns - 1) / columns
+	}
+	perSprite := columns * sb.Rows
+
+	for i := 0; i < sb.Tiles; i++ {
+		thumb, err := ThumbnailAt(reader, float32(i)*config.Interval, tw, th)
+		if err != nil {
+			return nil, err
+		}
+
+		if i%perSprite == 0 {
+			//the last sprite sheet only has the rows it needs
+			rows := intMin(sb.Rows, (sb.Tiles-i+columns-1)/columns)
+			sb.Sprites = append(sb.Sprites, image.NewNRGBA(image.Rect(0, 0, columns*tw, rows*th)))
+		}
+
+		sprite := sb.Sprites[len(sb.Sprites)-1]
+		x, y := sb.tilePosition(i)
+		b := sprite.Bounds()
+		drawOverRGBA(sprite.Pix, b.Dx(), b.Dy(), thumb.Image.Pix, tw, th, x, y, 255)
+	}
+
+	return sb, nil
+}
+
+// tilePosition returns the position of tile i on its sprite sheet
+func (sb *Storyboard) tilePosition(i int) (x, y int) {
+	i %= sb.Columns * sb.Rows
+	return i % sb.Columns * sb.TileWidth, i / sb.Columns * sb.TileHeight
+}
+
+// WriteWebVTT writes a WebVTT file with a cue for each tile which refers to its sprite sheet with a #xywh fragment.
+// spriteURL returns the url of sprite sheet i as used in the player.
+func (sb *Storyboard) WriteWebVTT(w io.Writer, spriteURL func(i int) string) error {
+	if _, err := io.WriteString(w, "WEBVTT\n\n"); err != nil {
+		return err
+	}
+
+	perSprite := sb.Columns * sb.Rows
+
+	for i := 0; i < sb.Tiles; i++ {
+		start := float32(i) * sb.Interval
+		end := float32(math.Min(float64(start+sb.Interval), float64(sb.Duration)))
+		x, y := sb.tilePosition(i)
+
+		_, err := fmt.Fprintf(w, "%s --> %s\n%s#xywh=%d,%d,%d,%d\n\n",
+			formatTimestamp(start, ".", 3),
+			formatTimestamp(end, ".", 3),
+			spriteURL(i/perSprite),
+			x, y, sb.TileWidth, sb.TileHeight,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package gomovie_test
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestEvenlySpacedThumbnails(t *testing.T) {
+	reader := gomovie.Concat(colorClip(color.White, 2), colorClip(color.Black, 2), colorClip(color.White, 2)).FrameReader
+
+	thumbs, err := gomovie.Thumbnails(reader, gomovie.ThumbnailConfig{Count: 3, Width: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(thumbs) != 3 {
+		t.Fatalf("Expected 3 thumbnails but got %v", len(thumbs))
+	}
+
+	for i, v := range []uint8{255, 0, 255} {
+		if thumbs[i].Time != float32(i*2+1) || thumbs[i].Image.Pix[0] != v {
+			t.Fatalf("Unexpected thumbnail %v at %v", i, thumbs[i].Time)
+		}
+	}
+
+	if b := thumbs[0].Image.Bounds(); b.Dx() != 4 || b.Dy() != 4 {
+		t.Fatalf("Expected a 4x4 thumbnail but got %v", b)
+	}
+
+	//the reader is not consumed
+	if f, err := reader.ReadFrame(); err != nil || f.Index != 0 {
+		t.Fatalf("Expected the first frame but got %v", err)
+	}
+}
+
+func TestSceneBasedThumbnails(t *testing.T) {
+	reader := gomovie.Concat(colorClip(color.White, 1), colorClip(color.Black, 3), colorClip(color.White, 2)).FrameReader
+
+	thumbs, err := gomovie.Thumbnails(reader, gomovie.ThumbnailConfig{Count: 2, Selection: gomovie.SceneBased})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(thumbs) != 2 || !near(thumbs[0].Time, 2.5) || !near(thumbs[1].Time, 5) {
+		t.Fatalf("Expected thumbnails in the middle of the longest shots but got %v", len(thumbs))
+	}
+}
+
+func TestSharpestThumbnail(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 8, Height: 8, FrameRate: 25, Duration: .5}
+	reader := gomovie.Concat(colorClip(color.Gray{128}, 1.5), gomovie.NewCheckerboardClip(1, color.White, color.Black, info)).FrameReader
+
+	thumbs, err := gomovie.Thumbnails(reader, gomovie.ThumbnailConfig{Count: 1, Selection: gomovie.Sharpest, Candidates: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(thumbs) != 1 || thumbs[0].Time < 1.5 {
+		t.Fatalf("Expected the checkerboard but got %v", thumbs[0].Time)
+	}
+}
+
+func TestContactSheet(t *testing.T) {
+	reader := gomovie.NewTestPatternClip(&gomovie.FrameReaderInfo{Width: 64, Height: 36, FrameRate: 25, Duration: 5})
+
+	thumbs, err := gomovie.Thumbnails(reader, gomovie.ThumbnailConfig{Count: 5, Width: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	style := gomovie.DefaultContactSheetStyle
+	style.Columns, style.Spacing = 2, 4
+
+	sheet := gomovie.ContactSheet(thumbs, style)
+	if b := sheet.Bounds(); b.Dx() != 76 || b.Dy() != 70 {
+		t.Fatalf("Expected a 76x70 sheet but got %v", b)
+	}
+}
+
+func TestStoryboard(t *testing.T) {
+	reader := gomovie.NewTestPatternClip(&gomovie.FrameReaderInfo{Width: 64, Height: 36, FrameRate: 25, Duration: 10})
+
+	sb, err := gomovie.NewStoryboard(reader, gomovie.StoryboardConfig{Interval: 2, Width: 32, Columns: 2, Rows: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sb.Tiles != 5 || len(sb.Sprites) != 2 {
+		t.Fatalf("Expected 5 tiles on 2 sprites but got %v on %v", sb.Tiles, len(sb.Sprites))
+	}
+
+	if b := sb.Sprites[1].Bounds(); b.Dx() != 64 || b.Dy() != 18 {
+		t.Fatalf("Expected a single row on the last sprite but got %v", b)
+	}
+
+	var buf bytes.Buffer
+	if err := sb.WriteWebVTT(&buf, func(i int) string { return []string{"a.jpg", "b.jpg"}[i] }); err != nil {
+		t.Fatal(err)
+	}
+
+	vtt := buf.String()
+	if !strings.HasPrefix(vtt, "WEBVTT") || !strings.Contains(vtt, "00:00:06.000 --> 00:00:08.000\na.jpg#xywh=32,18,32,18") ||
+		!strings.Contains(vtt, "00:00:08.000 --> 00:00:10.000\nb.jpg#xywh=0,0,32,18") {
+		t.Fatalf("Unexpected vtt %v", vtt)
+	}
+}
+
+func TestStoryboardInvalidInterval(t *testing.T) {
+	reader := gomovie.NewTestPatternClip(&gomovie.FrameReaderInfo{Width: 64, Height: 36, FrameRate: 25, Duration: 10})
+
+	if _, err := gomovie.NewStoryboard(reader, gomovie.StoryboardConfig{}); err == nil {
+		t.Fatal("Expected an error for a storyboard without an interval")
+	}
+}
@@ -1,36 +1,19 @@
 package gomovie
 
-import (
-	"bytes"
-	"encoding/binary"
-	"io"
-	"sort"
-	"sync"
-)
-
 const (
 	DefaultParallel = 5
 )
 
-type sortedFrames []*Frame
-
-func (s sortedFrames) Len() int {
-	return len(s)
-}
-
-func (s sortedFrames) Less(i int, j int) bool {
-	return s[i].Index < s[j].Index
-}
-
-func (s sortedFrames) Swap(i int, j int) {
-	s[i], s[j] = s[j], s[i]
-}
-
 // FrameTransform Describes the frame transform operation. Each transform should modify the Bytes field.
-// The resize operation is optional and is called before the transform. The resize operation should modify the Width and Height of the frame.
+// The resize operation is optional and is called before the transform. The resize operation should modify the Width and Height of the frame
+// and scale the Data accordingly. The transform operation is optional when a resize operation is given.
+// Resize is also called with a frame without Data to determine the size for Info. In that case only the Width and Height should be modified.
+// The Data of a frame is never shared with other frames so the transforms can modify it in place.
+// A Temporal transform is applied on its own after the transforms before it. Transform and Resize are ignored when it is set.
 type FrameTransform struct {
 	Transform func(f *Frame)
 	Resize    func(f *Frame)
+	Temporal  *TemporalTransform
 }
 
 // NewFrameTransformer convenience constructor to create a new FrameTransformer from a Video or an FrameReader
@@ -51,14 +34,10 @@ type FrameTransformer struct {
 
 	transforms    []FrameTransform
 	ParallelCount int
+	offset        float32 //start of the slice in the time of the transforms
 
-	processing bool
-	todo       chan *Frame
-	done       chan *Frame
-	buffer     sortedFrames
-	current    *Frame
-	nextIndex  int
-	quit       chan bool
+	pipeline *Pipeline
+	current  []byte
 }
 
 // AddTransform appends a transform to the frame transform list
@@ -67,142 +46,167 @@ func (ft *FrameTransformer) AddTransform(f FrameTransform) *FrameTransformer {
 	return ft
 }
 
-func (ft *FrameTransformer) Close() error {
-	ft.quit <- true
-	close(ft.todo)
-	return nil
+// Slice returns a new FrameTransformer with the same transforms for a slice of the source FrameReader
+func (ft *FrameTransformer) Slice(r *Range) FrameReader {
+	return &FrameTransformer{
+		FrameReader:   ft.FrameReader.Slice(r),
+		transforms:    ft.transforms,
+		ParallelCount: ft.ParallelCount,
+		offset:        ft.offset + r.Start,
+	}
 }
 
-func (ft *FrameTransformer) Read(p []byte) (int, error) {
-	if !ft.processing {
-		parallel := ft.ParallelCount
-		if parallel == 0 {
-			parallel = DefaultParallel
-		}
+// Info returns the info of the source FrameReader with the Width and Height after all the resizes
+func (ft *FrameTransformer) Info() *FrameReaderInfo {
+	i := *ft.FrameReader.Info()
 
-		ft.todo = make(chan *Frame, parallel)
-		ft.done = make(chan *Frame)
-		ft.processing = true
-
-		go ft.process(parallel)
+	f := &Frame{Width: i.Width, Height: i.Height}
+	for _, transform := range ft.transforms {
+		if transform.Resize != nil && transform.Temporal == nil {
+			transform.Resize(f)
+		}
 	}
 
-	var i int
-
-	for i = 0; i < 100; i++ {
+	i.Width = f.Width
+	i.Height = f.Height
+	return &i
+}
 
-		if ft.current == nil && len(ft.buffer) > 0 {
-			sort.Sort(ft.buffer) //todo don't always sort
+//Close stops the processing and closes the source FrameReader
+func (ft *FrameTransformer) Close() error {
+	if ft.pipeline != nil {
+		ft.pipeline.Close()
+	}
+	return ft.FrameReader.Close()
+}
 
-			if ft.buffer[0].Index == ft.nextIndex {
-				ft.current = ft.buffer[0]
-			}
+func (ft *FrameTransformer) Read(p []byte) (int, error) {
+	if len(ft.current) == 0 {
+		f, err := ft.ReadFrame()
+		if err != nil {
+			return 0, err
 		}
+		ft.current = f.Data
+	}
 
-		if ft.current != nil { //found something
-			c := copy(p, ft.current.Data)
-
-			if c == 0 { //nothing could be copied so we should go to the next frame
-				ft.nextIndex++
-				ft.buffer = ft.buffer[1:]
-				ft.current = nil
-			} else {
-				ft.current.Data = ft.current.Data[c:]
-				return c, nil
-			}
-
-		} else {
-
-			f, ok := <-ft.done
-			if !ok { //closed channel!
-				//if done is closed but we still have data in the buffer something is really wrong and we are missing some frames
-				break
-			}
+	n := copy(p, ft.current)
+	ft.current = ft.current[n:]
+	return n, nil
+}
 
-			ft.buffer = append(ft.buffer, f)
+//ReadFrame returns the next transformed frame. The frames are transformed by ParallelCount workers ahead of the reads
+//and returned in the order of the source.
+func (ft *FrameTransformer) ReadFrame() (*Frame, error) {
+	if ft.pipeline == nil {
+		parallel := ft.ParallelCount
+		if parallel == 0 {
+			parallel = DefaultParallel
 		}
 
-	}
-
-	err := io.EOF
+		source := func() (interface{}, error) {
+			return ft.FrameReader.ReadFrame()
+		}
 
-	if i == 1000 {
-		err = io.ErrNoProgress //don't know what happened here
+		ft.pipeline = NewPipeline(source, parallel*2, ft.stages(parallel)...)
 	}
 
-	return 0, err
-}
-
-//read a single frame and apply the transforms to it
-func (ft *FrameTransformer) ReadFrame() (*Frame, error) {
-	f, err := ft.FrameReader.ReadFrame()
+	f, err := ft.pipeline.Next()
 	if err != nil {
 		return nil, err
 	}
-
-	fc := *f
-	fp := &fc
-	ft.applyResizes(fp)
-	ft.applyTransforms(fp)
-	return fp, nil
+	return f.(*Frame), nil
 }
 
-func (ft *FrameTransformer) applyResizes(f *Frame) {
+//stages splits the transforms in stages. The transforms between temporal transforms are applied in a single stage.
+func (ft *FrameTransformer) stages(parallel int) (stages []Stage) {
+	var pending []FrameTransform
+
+	flush := func() {
+		transforms := pending
+		pending = nil
+
+		stages = append(stages, Stage{
+			Parallel: parallel,
+			Process: func(item interface{}) (interface{}, error) {
+				fc := *item.(*Frame)
+				ft.applyTransforms(&fc, transforms)
+				return &fc, nil
+			},
+		})
+	}
+
 	for _, transform := range ft.transforms {
-		if transform.Resize != nil {
-			transform.Resize(f)
+		if transform.Temporal == nil {
+			pending = append(pending, transform)
+			continue
+		}
+
+		if len(pending) > 0 {
+			flush()
 		}
+		stages = append(stages, ft.temporalStage(transform.Temporal, parallel))
 	}
-}
 
-func (ft *FrameTransformer) applyTransforms(f *Frame) {
-	for _, transform := range ft.transforms {
-		transform.Transform(f)
+	//always copy the frame so the frames of the source are never returned
+	if len(pending) > 0 || len(stages) == 0 {
+		flush()
 	}
+
+	return
 }
 
-func (ft *FrameTransformer) process(parallel int) {
-	//read until there are no more frames
-	go func() {
-		for {
-			f, err := ft.FrameReader.ReadFrame()
-			if err != nil { //no more frames! (or a strange error)
-				close(ft.todo)
-				break
+//temporalStage applies the temporal transform to a copy of each frame with a window of the frames around it
+func (ft *FrameTransformer) temporalStage(t *TemporalTransform, parallel int) Stage {
+	return Stage{
+		Parallel: parallel,
+		Before:   t.Before,
+		After:    t.After,
+		Process: func(item interface{}) (interface{}, error) {
+			w := item.(*StageWindow)
+
+			window := make([]*Frame, len(w.Items))
+			for i, v := range w.Items {
+				fc := *v.(*Frame)
+				fc.Time += ft.offset
+				window[i] = &fc
 			}
 
-			select {
-			case ft.todo <- f:
-			case <-ft.quit:
-				return
-			}
-		}
-	}()
-
-	wg := sync.WaitGroup{}
-	wg.Add(parallel)
-
-	//create x number of frame transformers which monitor the todo list
-	for i := 0; i < parallel; i++ {
-		go func() {
-			defer wg.Done()
-			for f := range ft.todo {
-				ft.applyResizes(f)
-				ft.applyTransforms(f)
-				ft.done <- f
-			}
-		}()
+			//the window frames might be shared so the transform gets its own data
+			f := *window[w.Current]
+			f.Data = append([]byte(nil), f.Data...)
+
+			t.Transform(&f, window, w.Current)
+
+			f.Time -= ft.offset
+			return &f, nil
+		},
 	}
+}
 
-	wg.Wait()
+//applies the transforms in order. The resize of a transform is done just before its own transform
+//The transforms get the time before any Slice so animations stay at the same position in the source
+func (ft *FrameTransformer) applyTransforms(f *Frame, transforms []FrameTransform) {
+	f.Time += ft.offset
+	defer func() { f.Time -= ft.offset }()
 
-	//all frame transformers were done so we can signal that the done channel needs to be closed
-	close(ft.done)
+	for _, transform := range transforms {
+		if transform.Resize != nil {
+			transform.Resize(f)
+		}
+		if transform.Transform != nil {
+			transform.Transform(f)
+		}
+	}
 }
 
 // SampleTransform Describes the sample transform operation. Each transform should modify the SampleBlock.
+// Transforms which need state across blocks (like filters) use Init instead of Transform.
 type SampleTransform struct {
 	Transform func(s *SampleBlock)
+
+	// Init is called once for every stream (the first read of a SampleTransformer or a Slice of it).
+	// It returns the transform for that stream so the state is never shared. When set Transform is ignored.
+	Init func(info *SampleReaderInfo) func(s *SampleBlock)
 }
 
 // NewSampleTransformer convenience constructor to create a new SampleTransformer from a Video or an SampleReader
@@ -218,12 +222,19 @@ func NewSampleTransformer(src interface{}) *SampleTransformer {
 }
 
 // SampleTransformer applies a transform to each sample block. Great for audio editing. implements the SampleReader interface.
+// The SampleFormat belongs to the transformer. The source is read with the format of the first read and every block is
+// converted to the format at the time it is returned, so the format can be changed while the blocks are read ahead.
 type SampleTransformer struct {
 	SampleReader
 
 	transforms []SampleTransform
+	active     []func(s *SampleBlock)
+	offset     float32 //start of the slice in the time of the transforms
 
-	sbData []byte
+	o        *SampleFormat
+	pipeline *Pipeline
+	sbData   []byte
+	sbBuf    []byte
 }
 
 // AddTransform appends a transform to the frame transform list
@@ -232,9 +243,39 @@ func (ft *SampleTransformer) AddTransform(f SampleTransform) *SampleTransformer
 	return ft
 }
 
+// Slice returns a new SampleTransformer for the range with the same transforms. Stateful transforms start with a clean state.
+func (ft *SampleTransformer) Slice(r *Range) SampleReader {
+	o := *ft.SampleFormat()
+	return &SampleTransformer{SampleReader: ft.SampleReader.Slice(r), transforms: ft.transforms, offset: ft.offset + r.Start, o: &o}
+}
+
+// SampleFormat returns the format of the transformed blocks. Starts as a copy of the format of the source.
+func (ft *SampleTransformer) SampleFormat() *SampleFormat {
+	if ft.o == nil {
+		o := *ft.SampleReader.SampleFormat()
+		ft.o = &o
+	}
+	return ft.o
+}
+
+//the transforms get the time before any Slice so envelopes stay at the same position in the source
 func (ft *SampleTransformer) applyTransforms(s *SampleBlock) {
-	for _, transform := range ft.transforms {
-		transform.Transform(s)
+	s.Time += ft.offset
+	defer func() { s.Time -= ft.offset }()
+
+	//initialize the transforms which were added since the last block
+	for _, transform := range ft.transforms[len(ft.active):] {
+		if transform.Init != nil {
+			ft.active = append(ft.active, transform.Init(ft.Info()))
+		} else {
+			ft.active = append(ft.active, transform.Transform)
+		}
+	}
+
+	for _, transform := range ft.active {
+		if transform != nil {
+			transform(s)
+		}
 	}
 }
 
@@ -245,11 +286,9 @@ func (ft *SampleTransformer) Read(p []byte) (int, error) {
 			return 0, err
 		}
 
-		//this will convert the sample block data to bytes
-		buf := new(bytes.Buffer)
-		binary.Write(buf, binary.LittleEndian, sb.Data)
-
-		ft.sbData = buf.Bytes()
+		//this will convert the sample block data to bytes. The buffer is reused for the next block.
+		ft.sbBuf = sb.AppendBytes(ft.sbBuf[:0])
+		ft.sbData = ft.sbBuf
 	}
 
 	n := copy(p, ft.sbData)
@@ -258,15 +297,58 @@ func (ft *SampleTransformer) Read(p []byte) (int, error) {
 	return n, nil
 }
 
-//ReadSampleBlock Read a single sampleblock which contains an array of int16 or int32 values (depending on the SampleFormat)
+//ReadSampleBlock Read a single sampleblock which contains an array of int16, int24, int32 or float32 values (depending on the SampleFormat)
+//The blocks are transformed in order by a single worker a few blocks ahead of the reads. Sample transforms keep state between blocks
+//so they can't run in parallel.
 func (ft *SampleTransformer) ReadSampleBlock() (*SampleBlock, error) {
-	sb, err := ft.SampleReader.ReadSampleBlock()
+	if ft.pipeline == nil {
+		//only the pipeline uses the format of the source from now on
+		*ft.SampleReader.SampleFormat() = *ft.SampleFormat()
+
+		source := func() (interface{}, error) {
+			sb, err := ft.SampleReader.ReadSampleBlock()
+			if err != nil {
+				return nil, err
+			}
+
+			//the block gets a snapshot of the format as the source might change its format for the next block
+			sc := *sb
+			format := *sb.SampleFormat
+			sc.SampleFormat = &format
+			return &sc, nil
+		}
+
+		transform := Stage{
+			Process: func(item interface{}) (interface{}, error) {
+				sb := item.(*SampleBlock)
+				ft.applyTransforms(sb)
+				return sb, nil
+			},
+		}
+
+		ft.pipeline = NewPipeline(source, 4, transform)
+	}
+
+	item, err := ft.pipeline.Next()
 	if err != nil {
 		return nil, err
 	}
 
-	sc := *sb
-	sp := &sc
-	ft.applyTransforms(sp)
-	return sp, nil
+	sb := item.(*SampleBlock)
+
+	//the block was read with the format of the first read
+	format := *ft.SampleFormat()
+	if f := *sb.SampleFormat; f.Depth != format.Depth || f.Float != format.Float || f.Layout != format.Layout {
+		sb = sb.ConvertTo(&format)
+	}
+
+	return sb, nil
+}
+
+//Close stops the processing and closes the source SampleReader
+func (ft *SampleTransformer) Close() error {
+	if ft.pipeline != nil {
+		ft.pipeline.Close()
+	}
+	return ft.SampleReader.Close()
 }
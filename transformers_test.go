@@ -2,7 +2,9 @@ package gomovie_test
 
 import (
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Remcoman/gomovie"
 )
@@ -75,3 +77,123 @@ func TestTransformSamples(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// closeCounter counts the calls to Close of the FrameReader and remembers when a frame was read after it was closed
+type closeCounter struct {
+	gomovie.FrameReader
+
+	mu             sync.Mutex
+	closed         int
+	readAfterClose bool
+	delay          time.Duration
+}
+
+func (c *closeCounter) ReadFrame() (*gomovie.Frame, error) {
+	f, err := c.FrameReader.ReadFrame()
+	time.Sleep(c.delay)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed > 0 {
+		c.readAfterClose = true
+	}
+	return f, err
+}
+
+func (c *closeCounter) Close() error {
+	c.mu.Lock()
+	c.closed++
+	c.mu.Unlock()
+	return c.FrameReader.Close()
+}
+
+func TestFrameTransformerClose(t *testing.T) {
+	info := &gomovie.FrameReaderInfo{Width: 4, Height: 4, FrameRate: 25, Duration: 10}
+
+	//closing before reading doesn't block
+	unread := &closeCounter{FrameReader: gomovie.NewNullFrameReader(info)}
+	if err := gomovie.NewFrameTransformer(unread).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if unread.closed != 1 {
+		t.Fatalf("Expected the source to be closed")
+	}
+
+	//closing while reading stops the processing. The source is closed once it is no longer read.
+	source := &closeCounter{FrameReader: gomovie.NewNullFrameReader(info), delay: time.Millisecond}
+	ft := gomovie.NewFrameTransformer(source)
+	ft.AddTransform(gomovie.FrameTransform{Transform: func(f *gomovie.Frame) {}})
+
+	if _, err := ft.ReadFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ft.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if source.closed != 1 {
+		t.Fatalf("Expected the source to be closed")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	source.mu.Lock()
+	if source.readAfterClose {
+		t.Fatalf("Expected no reads after the source was closed")
+	}
+	source.mu.Unlock()
+
+	if _, err := ft.ReadFrame(); err == nil {
+		t.Fatalf("Expected an error after Close")
+	}
+}
+
+func TestSampleTransformerFormatChange(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 1}
+	transformer := gomovie.NewSampleTransformer(gomovie.NewToneReader(gomovie.Sine, 100, .5, info))
+
+	if _, err := transformer.ReadSampleBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	//the next block uses the new format even though it was read ahead
+	format := transformer.SampleFormat()
+	format.Depth, format.Float = 32, true
+
+	sb, err := transformer.ReadSampleBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.Float32() == nil {
+		t.Fatalf("Expected float samples after changing the format")
+	}
+}
+
+// sampleCloseCounter counts the calls to Close of the SampleReader
+type sampleCloseCounter struct {
+	gomovie.SampleReader
+	closed int
+}
+
+func (c *sampleCloseCounter) Close() error {
+	c.closed++
+	return c.SampleReader.Close()
+}
+
+func TestSampleTransformerClose(t *testing.T) {
+	info := &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 1, Duration: 10}
+
+	source := &sampleCloseCounter{SampleReader: gomovie.NewToneReader(gomovie.Sine, 100, .5, info)}
+	transformer := gomovie.NewSampleTransformer(source).AddTransform(gomovie.NewVolumeTransform(gomovie.Constant(.5)))
+
+	if _, err := transformer.ReadSampleBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := transformer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if source.closed != 1 {
+		t.Fatalf("Expected the source to be closed")
+	}
+	if _, err := transformer.ReadSampleBlock(); err == nil {
+		t.Fatalf("Expected an error after Close")
+	}
+}
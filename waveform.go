@@ -0,0 +1,368 @@
+package gomovie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+var (
+	invalidPeaksBitsError            = errors.New("Peaks bits should be 8 or 16")
+	invalidPeaksSamplesPerPixelError = errors.New("Peaks samples per pixel should be at least 1")
+)
+
+// Peaks contains the lowest and highest sample of each channel for every SamplesPerPixel frames
+type Peaks struct {
+	SampleRate      int
+	SamplesPerPixel int
+	Channels        int
+
+	// Min and Max of each pixel for each channel. Between -1 and 1.
+	Min, Max [][]float32
+}
+
+// Length returns the number of pixels
+func (p *Peaks) Length() int {
+	if len(p.Min) == 0 {
+		return 0
+	}
+	return len(p.Min[0])
+}
+
+// scanPeaks reads the reader and calls fn with the min and max of each channel for every samplesPerPixel frames
+func scanPeaks(reader SampleReader, samplesPerPixel int, fn func(min, max []float32) error) error {
+	channels := reader.Info().Channels
+	input := newFloatFrameReader(reader)
+
+	min := make([]float32, channels)
+	max := make([]float32, channels)
+
+	for {
+		frames, err := input.read(samplesPerPixel)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for c := range min {
+			min[c], max[c] = 0, 0
+		}
+
+		for i, v := range frames {
+			c := i % channels
+			if v < min[c] {
+				min[c] = v
+			}
+			if v > max[c] {
+				max[c] = v
+			}
+		}
+
+		if err := fn(min, max); err != nil {
+			return err
+		}
+	}
+}
+
+// ComputePeaks reads the reader and returns the peaks for every samplesPerPixel frames
+func ComputePeaks(reader SampleReader, samplesPerPixel int) (*Peaks, error) {
+	if samplesPerPixel < 1 {
+		return nil, invalidPeaksSamplesPerPixelError
+	}
+
+	info := reader.Info()
+
+	p := &Peaks{
+		SampleRate:      info.SampleRate,
+		SamplesPerPixel: samplesPerPixel,
+		Channels:        info.Channels,
+		Min:             make([][]float32, info.Channels),
+		Max:             make([][]float32, info.Channels),
+	}
+
+	err := scanPeaks(reader, samplesPerPixel, func(min, max []float32) error {
+		for c := range min {
+			p.Min[c] = append(p.Min[c], min[c])
+			p.Max[c] = append(p.Max[c], max[c])
+		}
+		return nil
+	})
+
+	return p, err
+}
+
+// PeaksFormat describes the file format of WritePeaks. The formats are compatible with the files of audiowaveform (version 2).
+type PeaksFormat int
+
+const (
+	PeaksJSON PeaksFormat = iota
+	PeaksBinary
+)
+
+// peaksEncoder writes the header and values of a peaks file
+type peaksEncoder struct {
+	w      *bufio.Writer
+	format PeaksFormat
+	bits   int
+	length int
+
+	written int
+}
+
+func (e *peaksEncoder) header(rate, samplesPerPixel, channels int) error {
+	if e.format == PeaksJSON {
+		_, err := fmt.Fprintf(e.w, `{"version":2,"channels":%d,"sample_rate":%d,"samples_per_pixel":%d,"bits":%d,"length":%d,"data":[`,
+			channels, rate, samplesPerPixel, e.bits, e.length)
+		return err
+	}
+
+	var flags uint32
+	if e.bits == 8 {
+		flags = 1
+	}
+
+	return binary.Write(e.w, binary.LittleEndian, []int32{2, int32(flags), int32(rate), int32(samplesPerPixel), int32(e.length), int32(channels)})
+}
+
+func (e *peaksEncoder) quantize(v float32) int {
+	scale := float64(int(1)<<uint(e.bits-1) - 1)
+	return int(math.Max(math.Min(math.Floor(float64(v)*scale+.5), scale), -scale-1))
+}
+
+// pixel writes the min and max of each channel. Pixels beyond the length of the header are dropped.
+func (e *peaksEncoder) pixel(min, max []float32) error {
+	if e.written >= e.length {
+		return nil
+	}
+
+	for c := range min {
+		for i, v := range []float32{min[c], max[c]} {
+			q := e.quantize(v)
+
+			var err error
+			switch {
+			case e.format == PeaksJSON:
+				sep := ","
+				if e.written == 0 && c == 0 && i == 0 {
+					sep = ""
+				}
+				_, err = fmt.Fprintf(e.w, "%s%d", sep, q)
+			case e.bits == 8:
+				err = e.w.WriteByte(byte(int8(q)))
+			default:
+				err = binary.Write(e.w, binary.LittleEndian, int16(q))
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	e.written++
+	return nil
+}
+
+func (e *peaksEncoder) finish(channels int) error {
+	//pad to the length of the header when the reader was shorter than its duration
+	silence := make([]float32, channels)
+	for e.written < e.length {
+		if err := e.pixel(silence, silence); err != nil {
+			return err
+		}
+	}
+
+	if e.format == PeaksJSON {
+		if _, err := e.w.WriteString("]}"); err != nil {
+			return err
+		}
+	}
+
+	return e.w.Flush()
+}
+
+// WritePeaks streams the peaks of the reader to w while reading. Bits should be 8 or 16.
+// The length in the header is calculated from the duration of the reader.
+func WritePeaks(w io.Writer, reader SampleReader, samplesPerPixel int, format PeaksFormat, bits int) error {
+	if bits != 8 && bits != 16 {
+		return invalidPeaksBitsError
+	}
+	if samplesPerPixel < 1 {
+		return invalidPeaksSamplesPerPixelError
+	}
+
+	info := reader.Info()
+	frames := int(math.Floor(float64(sampleReaderDuration(reader))*float64(info.SampleRate) + 1e-3))
+
+	e := &peaksEncoder{
+		w:      bufio.NewWriter(w),
+		format: format,
+		bits:   bits,
+		length: (frames + samplesPerPixel - 1) / samplesPerPixel,
+	}
+
+	if err := e.header(info.SampleRate, samplesPerPixel, info.Channels); err != nil {
+		return err
+	}
+
+	if err := scanPeaks(reader, samplesPerPixel, e.pixel); err != nil {
+		return err
+	}
+
+	return e.finish(info.Channels)
+}
+
+// WaveformStyle describes how a waveform is drawn
+type WaveformStyle struct {
+	Width, Height int
+
+	Color      color.Color
+	Background color.Color
+
+	// SplitChannels draws each channel in its own lane. Otherwise the channels are combined.
+	SplitChannels bool
+}
+
+// DefaultWaveformStyle is a 1280x240 light blue waveform on a dark background with a lane for each channel
+var DefaultWaveformStyle = WaveformStyle{
+	Width:         1280,
+	Height:        240,
+	Color:         color.NRGBA{110, 190, 255, 255},
+	Background:    color.NRGBA{20, 20, 30, 255},
+	SplitChannels: true,
+}
+
+// Render draws the peaks with the style. Each pixel column shows the lowest and highest value of the peaks it covers.
+func (p *Peaks) Render(style WaveformStyle) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, style.Width, style.Height))
+
+	if style.Background != nil {
+		fillRGBA(img.Pix, color.NRGBAModel.Convert(style.Background).(color.NRGBA))
+	}
+
+	fg := color.NRGBAModel.Convert(style.Color).(color.NRGBA)
+
+	lanes := 1
+	if style.SplitChannels {
+		lanes = intMax(p.Channels, 1)
+	}
+	laneHeight := float64(style.Height) / float64(lanes)
+
+	length := p.Length()
+	if length == 0 {
+		return img
+	}
+
+	for x := 0; x < style.Width; x++ {
+		from := x * length / style.Width
+		to := intMax((x+1)*length/style.Width, from+1)
+
+		for lane := 0; lane < lanes; lane++ {
+			lo, hi := float32(0), float32(0)
+
+			for c := 0; c < p.Channels; c++ {
+				if style.SplitChannels && c != lane {
+					continue
+				}
+				for i := from; i < to && i < length; i++ {
+					lo = float32(math.Min(float64(lo), float64(p.Min[c][i])))
+					hi = float32(math.Max(float64(hi), float64(p.Max[c][i])))
+				}
+			}
+
+			center := laneHeight * (float64(lane) + .5)
+			y0 := int(math.Floor(center - float64(hi)*laneHeight/2))
+			y1 := int(math.Ceil(center - float64(lo)*laneHeight/2))
+
+			//always draw at least a single pixel so silence shows as a line
+			if y1 <= y0 {
+				y1 = y0 + 1
+			}
+
+			for y := intMax(y0, 0); y < y1 && y < style.Height; y++ {
+				img.SetNRGBA(x, y, fg)
+			}
+		}
+	}
+
+	return img
+}
+
+// RenderWaveform reads the reader and draws its waveform with the style
+func RenderWaveform(reader SampleReader, style WaveformStyle) (*image.NRGBA, error) {
+	info := reader.Info()
+	frames := float64(sampleReaderDuration(reader)) * float64(info.SampleRate)
+
+	samplesPerPixel := intMax(int(math.Ceil(frames/float64(intMax(style.Width, 1)))), 1)
+
+	peaks, err := ComputePeaks(reader, samplesPerPixel)
+	if err != nil {
+		return nil, err
+	}
+
+	return peaks.Render(style), nil
+}
+
+// WriteWaveformPNG reads the reader and writes its waveform as a PNG
+func WriteWaveformPNG(w io.Writer, reader SampleReader, style WaveformStyle) error {
+	img, err := RenderWaveform(reader, style)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// newPlayheadClip shows the image fitted in the frame with a vertical line which moves from left to right over the duration
+func newPlayheadClip(img *image.NRGBA, playhead color.Color, duration float32, info *FrameReaderInfo) FrameReader {
+	b := img.Bounds()
+	info = sizedInfo(info, b)
+
+	if info.Duration == 0 {
+		info.Duration = duration
+	}
+
+	pix := DefaultFit.apply(img.Pix, b.Dx(), b.Dy(), info.Width, info.Height)
+	line := color.NRGBAModel.Convert(playhead).(color.NRGBA)
+
+	return newGenerator(info, false, func(f *Frame, t float32) {
+		copy(f.Data, pix)
+
+		if duration <= 0 {
+			return
+		}
+
+		x := int(float64(t/duration) * float64(f.Width))
+		if x < 0 || x >= f.Width {
+			return
+		}
+
+		for y := 0; y < f.Height; y++ {
+			o := (y*f.Width + x) * 4
+			f.Data[o], f.Data[o+1], f.Data[o+2], f.Data[o+3] = line.R, line.G, line.B, line.A
+		}
+	})
+}
+
+// NewWaveformClip creates a FrameReader for audiograms which shows the waveform of the reader with a white playhead.
+// The waveform is rendered from a Slice so the reader can still be used for the audio of the video.
+// When the Width or Height of info is 0 the size of the style is used, when the Duration is 0 the duration of the reader.
+func NewWaveformClip(reader SampleReader, style WaveformStyle, info *FrameReaderInfo) (FrameReader, error) {
+	duration := sampleReaderDuration(reader)
+
+	scan := reader.Slice(&Range{Start: 0, Duration: duration})
+	img, err := RenderWaveform(scan, style)
+	scan.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return newPlayheadClip(img, color.White, duration, info), nil
+}
@@ -0,0 +1,138 @@
+package gomovie_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image/color"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Remcoman/gomovie"
+)
+
+func TestComputePeaks(t *testing.T) {
+	peaks, err := gomovie.ComputePeaks(squareReader(1), 800)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if peaks.Length() != 10 {
+		t.Fatalf("Expected 10 peaks but got %v", peaks.Length())
+	}
+
+	if peaks.Min[0][3] > -.99 || peaks.Max[0][3] < .99 {
+		t.Fatalf("Expected peaks at full scale but got %v %v", peaks.Min[0][3], peaks.Max[0][3])
+	}
+}
+
+func TestWritePeaksJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gomovie.WritePeaks(&buf, squareReader(1), 800, gomovie.PeaksJSON, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	var file struct {
+		Version         int   `json:"version"`
+		Channels        int   `json:"channels"`
+		SampleRate      int   `json:"sample_rate"`
+		SamplesPerPixel int   `json:"samples_per_pixel"`
+		Bits            int   `json:"bits"`
+		Length          int   `json:"length"`
+		Data            []int `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &file); err != nil {
+		t.Fatal(err)
+	}
+
+	if file.Version != 2 || file.SampleRate != 8000 || file.Length != 10 || len(file.Data) != 20 {
+		t.Fatalf("Unexpected peaks file %+v", file)
+	}
+
+	if file.Data[0] != -127 || file.Data[1] != 127 {
+		t.Fatalf("Expected full scale peaks but got %v %v", file.Data[0], file.Data[1])
+	}
+}
+
+func TestWritePeaksBinary(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gomovie.WritePeaks(&buf, squareReader(1), 800, gomovie.PeaksBinary, 16); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.Len() != 24+10*2*2 {
+		t.Fatalf("Expected a header and 10 peaks but got %v bytes", buf.Len())
+	}
+
+	header := make([]int32, 6)
+	binary.Read(&buf, binary.LittleEndian, header)
+
+	if header[0] != 2 || header[1] != 0 || header[3] != 800 || header[4] != 10 || header[5] != 1 {
+		t.Fatalf("Unexpected header %v", header)
+	}
+
+	if err := gomovie.WritePeaks(&buf, squareReader(1), 800, gomovie.PeaksBinary, 12); err == nil {
+		t.Fatal("Expected an error for 12 bits")
+	}
+}
+
+func TestPeaksInvalidSamplesPerPixel(t *testing.T) {
+	if err := gomovie.WritePeaks(ioutil.Discard, squareReader(1), 0, gomovie.PeaksBinary, 16); err == nil {
+		t.Fatal("Expected an error for 0 samples per pixel")
+	}
+	if _, err := gomovie.ComputePeaks(squareReader(1), 0); err == nil {
+		t.Fatal("Expected an error for 0 samples per pixel")
+	}
+}
+
+func TestRenderWaveform(t *testing.T) {
+	style := gomovie.WaveformStyle{Width: 100, Height: 40, Color: color.White, Background: color.Black, SplitChannels: true}
+	reader := gomovie.NewToneReader(gomovie.Square, 100, .5, &gomovie.SampleReaderInfo{SampleRate: 8000, Channels: 2, Duration: 1})
+
+	img, err := gomovie.RenderWaveform(reader, style)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//a lane of 20 pixels for each channel with a peak of half the lane height
+	for _, y := range []int{10, 30} {
+		if c := img.NRGBAAt(50, y); c.R != 255 {
+			t.Fatalf("Expected the waveform at %v but got %v", y, c)
+		}
+	}
+
+	for _, y := range []int{1, 19, 21} {
+		if c := img.NRGBAAt(50, y); c.R != 0 {
+			t.Fatalf("Expected the background at %v but got %v", y, c)
+		}
+	}
+}
+
+func TestWaveformClip(t *testing.T) {
+	reader := squareReader(2)
+
+	clip, err := gomovie.NewWaveformClip(reader, gomovie.WaveformStyle{Width: 40, Height: 20, Color: color.White}, &gomovie.FrameReaderInfo{FrameRate: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info := clip.Info(); info.Width != 40 || info.Height != 20 || info.Duration != 2 {
+		t.Fatalf("Unexpected info %+v", info)
+	}
+
+	//the reader is not consumed
+	if b, err := reader.ReadSampleBlock(); err != nil || b.Time != 0 {
+		t.Fatalf("Expected the reader to start at 0 but got %v", err)
+	}
+
+	clip = clip.Slice(&gomovie.Range{Start: 1, Duration: 1})
+	f, err := clip.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the playhead is in the middle at 1 second
+	if c := f.Data[(5*40+20)*4:]; c[0] != 255 || c[3] != 255 {
+		t.Fatalf("Expected the playhead at 20 but got %v", c[:4])
+	}
+}